@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrDeadlineTooClose marks the error of a failed provider call that was
+// not retried because the context's deadline left too little time.
+var ErrDeadlineTooClose = errors.New("context deadline too close")
+
+// JitterStrategy says how Backoff randomises its delays, so that many
+// clients failing at once do not retry in lockstep.
+type JitterStrategy int
+
+const (
+	// FullJitter, the default, waits a random time between zero and the
+	// exponential delay.
+	FullJitter JitterStrategy = iota
+	// NoJitter waits exactly the exponential delay.
+	NoJitter
+	// EqualJitter waits half the exponential delay plus a random part of
+	// the other half.
+	EqualJitter
+	// DecorrelatedJitter waits a random time between Base and three times
+	// the previous delay, independently of the attempt number.
+	DecorrelatedJitter
+)
+
+// Backoff spaces out the retries of failed provider calls. The delay before
+// retry n is Base doubled n-1 times, capped at Max, and then randomised by
+// Jitter. A zero Base retries at once.
+type Backoff struct {
+	Base, Max time.Duration
+	Jitter    JitterStrategy
+	// Rand, if set, is the source of the jitter so delays can be
+	// reproduced from a seed; Clock, if set, is what is waited on.
+	Rand  *rand.Rand
+	Clock Clock
+}
+
+// Delay returns how long to wait before retry attempt (1-based), given the
+// previous delay, which only DecorrelatedJitter uses.
+func (b Backoff) Delay(attempt int, prev time.Duration) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+	limit := b.Max
+	if limit <= 0 {
+		limit = time.Duration(1<<63 - 1)
+	}
+
+	if b.Jitter == DecorrelatedJitter {
+		hi := 3 * max(prev, b.Base)
+		if hi <= b.Base {
+			return min(b.Base, limit)
+		}
+		return min(b.Base+b.randDuration(hi-b.Base), limit)
+	}
+
+	d := b.Base
+	for i := 1; i < attempt && d < limit; i++ {
+		if d > limit/2 {
+			d = limit
+			break
+		}
+		d *= 2
+	}
+	d = min(d, limit)
+	switch b.Jitter {
+	case NoJitter:
+		return d
+	case EqualJitter:
+		return d/2 + b.randDuration(d-d/2)
+	default:
+		return b.randDuration(d)
+	}
+}
+
+// randDuration returns a random duration in [0, d].
+func (b Backoff) randDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	if b.Rand != nil {
+		return time.Duration(b.Rand.Int63n(int64(d) + 1))
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// wait sleeps for d on b's clock, returning early with ctx's error if ctx
+// is done first.
+func (b Backoff) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-orRealClock(b.Clock).After(d):
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// timeLeft reports whether ctx's deadline, if any, is more than need away.
+func timeLeft(ctx context.Context, need time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > need
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestBackoffJitterBounds(t *testing.T) {
+	const base, maxDelay = 100 * time.Millisecond, 2 * time.Second
+	exp := func(attempt int) time.Duration { return min(base<<(attempt-1), maxDelay) }
+	for _, tc := range []struct {
+		name   string
+		jitter JitterStrategy
+		bounds func(attempt int, prev time.Duration) (lo, hi time.Duration)
+	}{
+		{"none", NoJitter, func(n int, _ time.Duration) (time.Duration, time.Duration) { return exp(n), exp(n) }},
+		{"full", FullJitter, func(n int, _ time.Duration) (time.Duration, time.Duration) { return 0, exp(n) }},
+		{"equal", EqualJitter, func(n int, _ time.Duration) (time.Duration, time.Duration) { return exp(n) / 2, exp(n) }},
+		{"decorrelated", DecorrelatedJitter, func(_ int, prev time.Duration) (time.Duration, time.Duration) {
+			return base, min(3*max(prev, base), maxDelay)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := Backoff{Base: base, Max: maxDelay, Jitter: tc.jitter, Rand: rand.New(rand.NewSource(1))}
+			distinct := make(map[time.Duration]bool)
+			for run := 0; run < 200; run++ {
+				var prev time.Duration
+				for attempt := 1; attempt <= 8; attempt++ {
+					d := b.Delay(attempt, prev)
+					lo, hi := tc.bounds(attempt, prev)
+					if d < lo || d > hi {
+						t.Fatalf("attempt %d after %v: delay %v outside [%v, %v]", attempt, prev, d, lo, hi)
+					}
+					distinct[d] = true
+					prev = d
+				}
+			}
+			if tc.jitter != NoJitter && len(distinct) < 100 {
+				t.Errorf("only %d distinct delays; not randomised", len(distinct))
+			}
+		})
+	}
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	var zero Backoff
+	if zero.Jitter != FullJitter {
+		t.Errorf("default jitter is %v, want FullJitter", zero.Jitter)
+	}
+	if d := zero.Delay(3, time.Second); d != 0 {
+		t.Errorf("zero Backoff waits %v", d)
+	}
+	if d := (Backoff{Base: time.Second, Jitter: NoJitter}).Delay(80, 0); d <= 0 {
+		t.Errorf("uncapped delay overflowed to %v", d)
+	}
+
+	seeded := func() []time.Duration {
+		b := Backoff{Base: time.Second, Rand: rand.New(rand.NewSource(42))}
+		var out []time.Duration
+		for i := 1; i <= 5; i++ {
+			out = append(out, b.Delay(i, 0))
+		}
+		return out
+	}
+	a, b := seeded(), seeded()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("same seed gave %v and %v", a, b)
+		}
+	}
+}
+
+func TestRetryStopsNearDeadline(t *testing.T) {
+	busy := errors.New("server busy")
+	for _, tc := range []struct {
+		name     string
+		deadline time.Duration
+		opts     ActionOptions
+		calls    int
+		tooClose bool
+	}{
+		{"backoff past the deadline", 50 * time.Millisecond,
+			ActionOptions{ErrorRetries: 3, Backoff: Backoff{Base: time.Second, Jitter: NoJitter}}, 1, true},
+		{"no time for the attempt", 200 * time.Millisecond,
+			ActionOptions{ErrorRetries: 3, Backoff: Backoff{Base: 10 * time.Millisecond, Jitter: NoJitter}, MinAttemptTime: time.Second}, 1, true},
+		{"third backoff past the deadline", 150 * time.Millisecond,
+			ActionOptions{ErrorRetries: 3, Backoff: Backoff{Base: 40 * time.Millisecond, Jitter: NoJitter}}, 3, true},
+		{"enough time", time.Minute,
+			ActionOptions{ErrorRetries: 2, Backoff: Backoff{Base: time.Millisecond, Jitter: NoJitter}}, 3, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "", busy }}
+			ctx, cancel := context.WithTimeout(context.Background(), tc.deadline)
+			defer cancel()
+
+			start := time.Now()
+			_, err := tc.opts.chat(ctx, p, CauseWriteCode, "hi")
+			if !errors.Is(err, busy) {
+				t.Errorf("got %v, want the last provider error", err)
+			}
+			if errors.Is(err, ErrDeadlineTooClose) != tc.tooClose {
+				t.Errorf("got %v, ErrDeadlineTooClose %v", err, tc.tooClose)
+			}
+			if p.calls() != tc.calls {
+				t.Errorf("%d calls, want %d", p.calls(), tc.calls)
+			}
+			if tc.tooClose && time.Since(start) >= tc.deadline {
+				t.Errorf("waited %v, until the deadline", time.Since(start))
+			}
+		})
+	}
+}
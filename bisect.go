@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// RoundState is the state of a team at the start of a round, as recorded
+// by RunWithTrace.
+type RoundState struct {
+	Round      int
+	Transcript []Message
+	// Memories holds every role's memory, by role name.
+	Memories map[string][]Message
+}
+
+// snapshot captures the team's current state as the start of round.
+func (t *Team) snapshot(round int) RoundState {
+	st := RoundState{Round: round, Transcript: t.Transcript(), Memories: make(map[string][]Message)}
+	for _, r := range t.roles() {
+		st.Memories[r.Name] = r.Memory.GetRecentN(-1)
+	}
+	return st
+}
+
+// Restore puts t back into st: the memories of roles found by name are set
+// back to their recorded messages, keeping the stores and their settings,
+// and the transcript and round count are reset, so that Resume replays the
+// run from that round.
+func (st RoundState) Restore(t *Team) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.transcript = append([]Message(nil), st.Transcript...)
+	t.round = st.Round
+	for _, r := range t.Roles {
+		if mem, ok := st.Memories[r.Name]; ok {
+			r.Memory = restoreMemory(r.Memory, mem)
+		}
+	}
+}
+
+// RunWithTrace is RunProjectRounds that also records the team's state at
+// the start of every round it runs, for Bisect.
+func (t *Team) RunWithTrace(ctx context.Context, rounds int) ([]RoundState, []Message, error) {
+	var trace []RoundState
+	t.mu.Lock()
+	t.onRoundStart = func(round int) { trace = append(trace, t.snapshot(round)) }
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.onRoundStart = nil
+		t.mu.Unlock()
+	}()
+
+	msgs, err := t.RunProjectRounds(ctx, rounds)
+	return trace, msgs, err
+}
+
+// Bisect finds the first round of trace from which a run fails. failsFrom
+// replays the run from a recorded state, typically by restoring it into a
+// fresh team and resuming, and reports whether the failure shows. Failures
+// are assumed to persist once introduced, so only about log2(len(trace))
+// replays are made. It returns the round, or -1 if no replay fails.
+func Bisect(ctx context.Context, trace []RoundState, failsFrom func(ctx context.Context, st RoundState) (bool, error)) (int, error) {
+	lo, hi := 0, len(trace)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		failed, err := failsFrom(ctx, trace[mid])
+		if err != nil {
+			return -1, fmt.Errorf("bisect: replaying round %d: %w", trace[mid].Round, err)
+		}
+		if failed {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	if lo == len(trace) {
+		return -1, nil
+	}
+	return trace[lo].Round, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// bugAction counts the messages it has produced before and, from the
+// message numbered at on, keeps replying "BUG": a failure introduced in a
+// known round that persists once it is in memory.
+type bugAction struct{ at int }
+
+func (a bugAction) Name() CauseBy { return "Step" }
+
+func (a bugAction) ContextWindow() int { return -1 }
+
+func (a bugAction) Run(ctx context.Context, contextData string) (string, error) {
+	return "", errors.New("step: want RunMessages")
+}
+
+func (a bugAction) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	steps := 0
+	for _, msg := range msgs {
+		if msg.Content == "BUG" {
+			return "BUG", nil
+		}
+		if msg.CauseBy == a.Name() {
+			steps++
+		}
+	}
+	if steps >= a.at {
+		return "BUG", nil
+	}
+	return fmt.Sprintf("step %d", steps), nil
+}
+
+func bugTeam(at int) *Team {
+	return &Team{ProjectIdea: "count", Output: io.Discard, Roles: []*Role{
+		{Name: "Stepper", Profile: "Stepper", Actions: []Action{bugAction{at}}, WatchList: []CauseBy{CauseUserRequirement}, Memory: &Memory{}},
+	}}
+}
+
+func TestBisectFindsFirstFailingRound(t *testing.T) {
+	const rounds = 8
+	for _, at := range []int{0, 3, 7, rounds} {
+		t.Run(fmt.Sprint(at), func(t *testing.T) {
+			trace, msgs, err := bugTeam(at).RunWithTrace(context.Background(), rounds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trace) != rounds || len(msgs) != rounds {
+				t.Fatalf("traced %d rounds and %d messages, want %d", len(trace), len(msgs), rounds)
+			}
+
+			replays := 0
+			failsFrom := func(ctx context.Context, st RoundState) (bool, error) {
+				replays++
+				team := bugTeam(at)
+				st.Restore(team)
+				produced, err := team.Resume(ctx, st.Round+1)
+				if err != nil {
+					return false, err
+				}
+				return len(produced) == 1 && produced[0].Content == "BUG", nil
+			}
+			round, err := Bisect(context.Background(), trace, failsFrom)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := at
+			if at >= rounds {
+				want = -1
+			}
+			if round != want {
+				t.Errorf("Bisect found round %d, want %d", round, want)
+			}
+			if replays > 4 {
+				t.Errorf("%d replays for %d rounds", replays, rounds)
+			}
+		})
+	}
+}
+
+func TestBisectReportsReplayErrors(t *testing.T) {
+	trace := []RoundState{{Round: 0}, {Round: 1}}
+	boom := errors.New("boom")
+	_, err := Bisect(context.Background(), trace, func(context.Context, RoundState) (bool, error) { return false, boom })
+	if !errors.Is(err, boom) {
+		t.Errorf("got %v, want the replay error", err)
+	}
+}
+
+func TestRestoreKeepsMemoryStores(t *testing.T) {
+	bounded := &Memory{MaxMessages: 1}
+	sharded := NewShardedMemory(2)
+	team := &Team{Roles: []*Role{{Name: "Alice", Memory: bounded}, {Name: "Bob", Memory: sharded}}}
+	idea := NewMessage(nil, "idea", "User", CauseUserRequirement)
+	st := RoundState{Round: 1, Memories: map[string][]Message{"Alice": {idea}, "Bob": {idea}}}
+	for _, r := range team.Roles {
+		r.Memory.Add(NewMessage(nil, "code", "SimpleCoder", CauseWriteCode))
+	}
+
+	st.Restore(team)
+	if team.Roles[0].Memory != bounded || team.Roles[1].Memory != sharded {
+		t.Fatal("Restore replaced the roles' memory stores")
+	}
+	for _, r := range team.Roles {
+		if got := r.Memory.GetRecentN(-1); len(got) != 1 || got[0].ID != idea.ID {
+			t.Errorf("%s: memory %v after Restore, want the idea", r.Name, causes(got))
+		}
+	}
+	bounded.Add(NewMessage(nil, "code", "SimpleCoder", CauseWriteCode))
+	if got := causes(bounded.GetRecentN(-1)); len(got) != 1 || got[0] != CauseWriteCode {
+		t.Errorf("MaxMessages lost by Restore: %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrRoleCancelled is returned by Role.Act when the role's current
+	// action was stopped with Role.Cancel.
+	ErrRoleCancelled = errors.New("role cancelled")
+	// errRoleRestart marks an action stopped by Role.Restart; the team
+	// dispatches the role again.
+	errRoleRestart = errors.New("role restarted")
+)
+
+// runningActions maps each acting *Role to the cancel function of its
+// current action context.
+var runningActions sync.Map
+
+type actionCancel struct {
+	cancel context.CancelCauseFunc
+}
+
+// actionContext derives the per-role context an action runs under, so the
+// role can be stopped without cancelling the rest of the team.
+func (r *Role) actionContext(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ac := &actionCancel{cancel: cancel}
+	runningActions.Store(r, ac)
+	return ctx, func() {
+		runningActions.CompareAndDelete(r, ac)
+		cancel(nil)
+	}
+}
+
+func (r *Role) stop(cause error) bool {
+	v, ok := runningActions.Load(r)
+	if !ok {
+		return false
+	}
+	v.(*actionCancel).cancel(cause)
+	return true
+}
+
+// Cancel stops the role's current action; Act returns ErrRoleCancelled and
+// the other roles keep running. It reports whether an action was running.
+func (r *Role) Cancel() bool { return r.stop(ErrRoleCancelled) }
+
+// Restart stops the role's current action and has the team dispatch the
+// role again in the same wave. It reports whether an action was running.
+func (r *Role) Restart() bool { return r.stop(errRoleRestart) }
+
+// stoppedCause returns the reason the role's action context was stopped
+// through Cancel or Restart, or nil.
+func stoppedCause(ctx context.Context) error {
+	cause := context.Cause(ctx)
+	if errors.Is(cause, ErrRoleCancelled) || errors.Is(cause, errRoleRestart) {
+		return cause
+	}
+	return nil
+}
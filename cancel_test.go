@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// blockAction blocks its first block runs until cancelled, then replies.
+type blockAction struct {
+	started chan struct{}
+	block   int32
+	runs    atomic.Int32
+}
+
+func (a *blockAction) Name() CauseBy { return "Block" }
+
+func (a *blockAction) Run(ctx context.Context, contextData string) (string, error) {
+	if a.runs.Add(1) > a.block {
+		return "finished", nil
+	}
+	a.started <- struct{}{}
+	<-ctx.Done()
+	return "", context.Cause(ctx)
+}
+
+func cancelTeam(block int32) (*Team, *Role, *blockAction) {
+	a := &blockAction{started: make(chan struct{}, 1), block: block}
+	slow := &Role{Name: "Slow", Profile: "Slow", Memory: &Memory{}, Actions: []Action{a}, WatchList: []CauseBy{CauseUserRequirement}}
+	fast := &Role{Name: "Fast", Profile: "Fast", Memory: &Memory{}, Actions: []Action{echoAction{"Echo"}}, WatchList: []CauseBy{CauseUserRequirement}}
+	return &Team{Roles: []*Role{slow, fast}, ProjectIdea: "idea", Output: io.Discard}, slow, a
+}
+
+func TestCancelOneRole(t *testing.T) {
+	team, slow, a := cancelTeam(1)
+	go func() {
+		<-a.started
+		if !slow.Cancel() {
+			t.Error("Cancel found no running action")
+		}
+	}()
+	msgs, err := team.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := causes(msgs); !slices.Equal(got, []CauseBy{"Echo"}) {
+		t.Errorf("run produced %v, want only the other role's message", got)
+	}
+	if slow.Cancel() {
+		t.Error("Cancel reported a running action after the run")
+	}
+}
+
+func TestRestartOneRole(t *testing.T) {
+	team, slow, a := cancelTeam(1)
+	go func() {
+		<-a.started
+		slow.Restart()
+	}()
+	msgs, err := team.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := causes(msgs); len(got) != 2 || !slices.Contains(got, "Block") {
+		t.Errorf("run produced %v, want both roles' messages", got)
+	}
+	if a.runs.Load() != 2 {
+		t.Errorf("action ran %d times, want 2", a.runs.Load())
+	}
+}
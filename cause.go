@@ -0,0 +1,36 @@
+package main
+
+import "strings"
+
+// CauseBy names what produced a message: the action whose Name it is, or
+// one of the team's own causes. Roles list the causes they watch. It is a
+// string underneath, so it stays a plain string in JSON.
+type CauseBy string
+
+// Causes of the built-in actions and of the messages the team adds itself.
+const (
+	CauseUserRequirement CauseBy = "UserRequirement"
+	CauseWriteCode       CauseBy = "SimpleWriteCode"
+	CauseWriteTest       CauseBy = "SimpleWriteTest"
+	CauseWriteReview     CauseBy = "SimpleWriteReview"
+	CauseWritePRD        CauseBy = "SimpleWritePRD"
+	CauseWriteSchema     CauseBy = "SimpleWriteSchema"
+	CauseWriteCommit     CauseBy = "SimpleWriteCommit"
+	CauseWriteReadme     CauseBy = "SimpleWriteReadme"
+	CauseEstimate        CauseBy = "SimpleEstimate"
+	CauseSummarize       CauseBy = "SimpleSummarize"
+	CauseTranslate       CauseBy = "SimpleTranslate"
+	CauseTestRun         CauseBy = "TestRun"
+	CauseCoverageCheck   CauseBy = "CoverageCheck"
+	CauseApproved        CauseBy = "Approved"
+	CauseRejected        CauseBy = "Rejected"
+)
+
+// joinCauses is strings.Join for causes.
+func joinCauses(causes []CauseBy, sep string) string {
+	s := make([]string, len(causes))
+	for i, c := range causes {
+		s[i] = string(c)
+	}
+	return strings.Join(s, sep)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCauseByJSON(t *testing.T) {
+	data, err := json.Marshal(Message{ID: "m1", CauseBy: CauseWriteCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"CauseBy":"SimpleWriteCode"`) {
+		t.Errorf("CauseBy not encoded as a plain string: %s", data)
+	}
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"ID":"m2","CauseBy":"SimpleWriteTest"}`), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.CauseBy != CauseWriteTest {
+		t.Errorf("decoded %q, want CauseWriteTest", msg.CauseBy)
+	}
+}
+
+func TestActionNamesAreCauses(t *testing.T) {
+	for want, a := range map[CauseBy]Action{
+		CauseWriteCode:   &SimpleWriteCode{},
+		CauseWriteTest:   &SimpleWriteTest{},
+		CauseWriteReview: &SimpleWriteReview{},
+		CauseWritePRD:    &SimpleWritePRD{},
+		CauseWriteSchema: &SimpleWriteSchema{},
+		CauseWriteReadme: &SimpleWriteReadme{},
+		CauseSummarize:   &SimpleSummarize{},
+		CauseTestRun:     &RunTests{},
+	} {
+		if got := a.Name(); got != want {
+			t.Errorf("%T is named %q, want %q", a, got, want)
+		}
+	}
+}
+
+func TestRoutingWithTypedCauses(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	watcher := &Role{Name: "Wes", Profile: "Watcher", Actions: []Action{&captureAction{window: -1}}, WatchList: []CauseBy{CauseWriteTest}}
+	team.AddRole(watcher)
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []CauseBy
+	for _, msg := range watcher.Memory.GetRecentN(-1) {
+		if msg.Role != watcher.Profile {
+			got = append(got, msg.CauseBy)
+		}
+	}
+	if len(got) != 2 || got[0] != CauseUserRequirement || got[1] != CauseWriteTest {
+		t.Errorf("watcher received %v, want the requirement and the tests", got)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrInjected is the failure the FailCalls and FailRate helpers inject.
+var ErrInjected = errors.New("injected failure")
+
+// ChaosProvider wraps a provider and fails the calls FailureFunc picks, to
+// exercise retry and failover paths deterministically.
+type ChaosProvider struct {
+	Provider LLMProvider
+	// FailureFunc is called with the 1-based index of every call; a
+	// non-nil error is returned in place of calling Provider.
+	FailureFunc func(callIndex int) error
+
+	calls atomic.Int64
+}
+
+func (c *ChaosProvider) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	n := int(c.calls.Add(1))
+	if c.FailureFunc != nil {
+		if err := c.FailureFunc(n); err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+	}
+	return c.Provider.CreateChatCompletion(ctx, req)
+}
+
+// Calls returns how many calls the provider has received.
+func (c *ChaosProvider) Calls() int { return int(c.calls.Load()) }
+
+// Name returns the name of the wrapped provider.
+func (c *ChaosProvider) Name() string { return providerName(c.Provider) }
+
+// FailCalls returns a FailureFunc failing exactly the calls with the given
+// 1-based indices.
+func FailCalls(indices ...int) func(int) error {
+	fail := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		fail[i] = true
+	}
+	return func(n int) error {
+		if fail[n] {
+			return fmt.Errorf("%w on call %d", ErrInjected, n)
+		}
+		return nil
+	}
+}
+
+// FailRate returns a FailureFunc failing each call with probability rate,
+// reproducibly for a given seed.
+func FailRate(rate float64, seed int64) func(int) error {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(seed))
+	return func(n int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if rng.Float64() < rate {
+			return fmt.Errorf("%w on call %d", ErrInjected, n)
+		}
+		return nil
+	}
+}
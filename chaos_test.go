@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// chaosTeam is the coder, tester and reviewer pipeline on llm, with every
+// action retrying failed calls retries times.
+func chaosTeam(llm LLMProvider, retries int) *Team {
+	opts := ActionOptions{ErrorRetries: retries}
+	return &Team{ProjectIdea: "write a function that returns the product of a list", Output: io.Discard, Roles: []*Role{
+		{Name: "Alice", Profile: "SimpleCoder", Actions: []Action{&SimpleWriteCode{llmClient: llm, ActionOptions: opts}}, WatchList: []CauseBy{CauseUserRequirement}, Memory: &Memory{}},
+		{Name: "Bob", Profile: "SimpleTester", Actions: []Action{&SimpleWriteTest{llmClient: llm, ActionOptions: opts}}, WatchList: []CauseBy{CauseWriteCode}, Memory: &Memory{}},
+		{Name: "Charlie", Profile: "SimpleReviewer", Actions: []Action{&SimpleWriteReview{llmClient: llm, ActionOptions: opts}}, WatchList: []CauseBy{CauseWriteTest}, Memory: &Memory{}},
+	}}
+}
+
+func TestTeamSurvivesInjectedFailures(t *testing.T) {
+	pipeline := []CauseBy{CauseWriteCode, CauseWriteTest, CauseWriteReview}
+	for _, tc := range []struct {
+		name    string
+		failure func(int) error
+		calls   int
+	}{
+		{"first call of every role", FailCalls(1, 3, 5), 6},
+		{"two in a row", FailCalls(2, 3), 5},
+		{"random", FailRate(0.4, 7), 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chaos := &ChaosProvider{Provider: newPipelineProvider(), FailureFunc: tc.failure}
+			msgs, err := chaosTeam(chaos, 5).RunProject(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := causes(msgs); !slices.Equal(got, pipeline) {
+				t.Errorf("team produced %v, want %v", got, pipeline)
+			}
+			if tc.calls > 0 && chaos.Calls() != tc.calls {
+				t.Errorf("%d calls, want %d", chaos.Calls(), tc.calls)
+			}
+		})
+	}
+}
+
+func TestInjectedFailuresWithoutRetries(t *testing.T) {
+	chaos := &ChaosProvider{Provider: newPipelineProvider(), FailureFunc: FailCalls(2)}
+	msgs, _ := chaosTeam(chaos, 0).RunProject(context.Background())
+	if got := causes(msgs); slices.Contains(got, CauseWriteTest) || !slices.Contains(got, CauseWriteCode) {
+		t.Errorf("team produced %v, want the code but no tests", got)
+	}
+}
+
+func TestFailHelpers(t *testing.T) {
+	fail := FailCalls(3)
+	for i := 1; i <= 4; i++ {
+		if err := fail(i); (err != nil) != (i == 3) || (err != nil && !errors.Is(err, ErrInjected)) {
+			t.Errorf("call %d: got %v", i, err)
+		}
+	}
+
+	a, b := FailRate(0.5, 42), FailRate(0.5, 42)
+	failed := 0
+	for i := 1; i <= 200; i++ {
+		ea, eb := a(i), b(i)
+		if (ea == nil) != (eb == nil) {
+			t.Fatalf("call %d differs for the same seed", i)
+		}
+		if ea != nil {
+			failed++
+		}
+	}
+	if failed < 60 || failed > 140 {
+		t.Errorf("FailRate(0.5) failed %d of 200 calls", failed)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// CheckProvider makes the smallest possible chat request to verify that the
+// provider is reachable and the credentials are accepted. The returned
+// error says which of the two failed.
+func CheckProvider(ctx context.Context, p LLMProvider) error {
+	_, err := p.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:     "gpt-4",
+		MaxTokens: 1,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: "ping"},
+		},
+	})
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.HTTPStatusCode == http.StatusUnauthorized || apiErr.HTTPStatusCode == http.StatusForbidden),
+		errors.As(err, &reqErr) && (reqErr.HTTPStatusCode == http.StatusUnauthorized || reqErr.HTTPStatusCode == http.StatusForbidden):
+		return fmt.Errorf("provider check: credentials rejected, check the API key: %w", err)
+	case isModelNotFound(err):
+		return fmt.Errorf("provider check: model or deployment not found: %w", err)
+	case errors.As(err, &netErr):
+		return fmt.Errorf("provider check: cannot reach the endpoint: %w", err)
+	default:
+		return fmt.Errorf("provider check: %w", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestCheckProvider(t *testing.T) {
+	ok := newChatServer(t, "pong")
+	if err := CheckProvider(context.Background(), NewOpenAIProvider(ok.config())); err != nil {
+		t.Errorf("healthy provider: %v", err)
+	}
+
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": {"message": "Incorrect API key provided", "type": "invalid_request_error", "code": "invalid_api_key"}}`))
+	}))
+	defer denied.Close()
+	config := openai.DefaultConfig("bad-key")
+	config.BaseURL = denied.URL + "/v1"
+	err := CheckProvider(context.Background(), NewOpenAIProvider(config))
+	if err == nil || !strings.Contains(err.Error(), "credentials rejected") {
+		t.Errorf("rejected key: got %v", err)
+	}
+
+	gone := httptest.NewServer(http.NotFoundHandler())
+	config.BaseURL = gone.URL + "/v1"
+	gone.Close()
+	err = CheckProvider(context.Background(), NewOpenAIProvider(config))
+	if err == nil || !strings.Contains(err.Error(), "cannot reach the endpoint") {
+		t.Errorf("unreachable endpoint: got %v", err)
+	}
+}
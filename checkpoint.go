@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// checkpoint is the on-disk form of a team. Actions hold live providers and
+// are not saved; only their names are kept for reference.
+type checkpoint struct {
+	ProjectIdea string
+	Round       int
+	UserReq     *Message
+	Transcript  []Message
+	Roles       []roleCheckpoint
+}
+
+type roleCheckpoint struct {
+	Name      string
+	Profile   string
+	WatchList []CauseBy
+	Priority  int
+	Actions   []CauseBy
+	Memory    []Message
+	// MemoryConfig holds the memory's settings; its zero value, as in
+	// checkpoints written before it existed, is a default Memory.
+	MemoryConfig memoryConfig
+}
+
+// memoryConfig is the saved form of a role's MemoryStore. Similarity and
+// Clock are functions and are not saved; reset them after loading.
+type memoryConfig struct {
+	// Shards is set for a ShardedMemory.
+	Shards         int           `json:",omitempty"`
+	MaxMessages    int           `json:",omitempty"`
+	DedupThreshold float64       `json:",omitempty"`
+	TTL            time.Duration `json:",omitempty"`
+}
+
+func memoryConfigOf(m MemoryStore) memoryConfig {
+	switch m := m.(type) {
+	case *Memory:
+		return memoryConfig{MaxMessages: m.MaxMessages, DedupThreshold: m.DedupThreshold, TTL: m.TTL}
+	case *ShardedMemory:
+		return memoryConfig{Shards: len(m.shards)}
+	}
+	return memoryConfig{}
+}
+
+// store returns a new MemoryStore with c's settings holding history.
+func (c memoryConfig) store(history []Message) MemoryStore {
+	if c.Shards > 0 {
+		m := NewShardedMemory(c.Shards)
+		m.replace(history)
+		return m
+	}
+	return &Memory{history: history, MaxMessages: c.MaxMessages, DedupThreshold: c.DedupThreshold, TTL: c.TTL}
+}
+
+// restoreMemory sets m's history to msgs, keeping m and its settings. A nil
+// or unknown store is replaced by a default Memory.
+func restoreMemory(m MemoryStore, msgs []Message) MemoryStore {
+	if r, ok := m.(interface{ replace([]Message) }); ok {
+		r.replace(msgs)
+		return m
+	}
+	return &Memory{history: append([]Message(nil), msgs...)}
+}
+
+// Checkpoint writes the team's state (every role's memory, the transcript
+// and the completed round count) to path as JSON. Call it between rounds,
+// for instance from the Supervisor hook.
+func (t *Team) Checkpoint(path string) error {
+	t.mu.Lock()
+	cp := checkpoint{
+		ProjectIdea: t.ProjectIdea,
+		Round:       t.round,
+		UserReq:     t.userReq,
+		Transcript:  append([]Message(nil), t.transcript...),
+	}
+	roles := append([]*Role(nil), t.Roles...)
+	t.mu.Unlock()
+
+	for _, r := range roles {
+		rc := roleCheckpoint{
+			Name:         r.Name,
+			Profile:      r.Profile,
+			WatchList:    r.WatchList,
+			Priority:     r.Priority,
+			Memory:       r.Memory.GetRecentN(-1),
+			MemoryConfig: memoryConfigOf(r.Memory),
+		}
+		for _, a := range r.Actions {
+			rc.Actions = append(rc.Actions, a.Name())
+		}
+		cp.Roles = append(cp.Roles, rc)
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	// Write to a temporary file first so a crash never leaves a torn
+	// checkpoint behind.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint restores a team saved with Checkpoint. The returned roles
+// have no actions: reattach them, with their providers, before calling
+// Resume. Use Team.Role to find a role by name. Each role gets back the
+// kind of memory it had, with its limits.
+func LoadCheckpoint(path string) (*Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	t := &Team{
+		ProjectIdea: cp.ProjectIdea,
+		userReq:     cp.UserReq,
+		transcript:  cp.Transcript,
+		round:       cp.Round,
+	}
+	for _, rc := range cp.Roles {
+		t.Roles = append(t.Roles, &Role{
+			Name:      rc.Name,
+			Profile:   rc.Profile,
+			WatchList: rc.WatchList,
+			Priority:  rc.Priority,
+			Memory:    rc.MemoryConfig.store(rc.Memory),
+		})
+	}
+	return t, nil
+}
+
+// Role returns the team member with the given name, or nil.
+func (t *Team) Role(name string) *Role {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.Roles {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
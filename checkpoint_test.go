@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCheckpointKeepsAttachments(t *testing.T) {
+	msg := NewMessage(nil, "code", "SimpleCoder", CauseWriteCode)
+	msg.Attachments = []Attachment{{Name: "requirements.txt", Content: []byte("requests==2.32.3\n")}}
+	r := &Role{Name: "Bob", Profile: "SimpleTester", Memory: &Memory{}}
+	r.Memory.Add(msg)
+	team := &Team{Roles: []*Role{r}}
+	team.transcript = append(team.transcript, msg)
+
+	path := filepath.Join(t.TempDir(), "team.json")
+	if err := team.Checkpoint(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for where, msgs := range map[string][]Message{
+		"memory":     loaded.Role("Bob").Memory.GetRecentN(-1),
+		"transcript": loaded.Transcript(),
+	} {
+		if len(msgs) != 1 || len(msgs[0].Attachments) != 1 {
+			t.Fatalf("%s: got %+v", where, msgs)
+		}
+		att := msgs[0].Attachments[0]
+		if att.Name != "requirements.txt" || !bytes.Equal(att.Content, msg.Attachments[0].Content) {
+			t.Errorf("%s: attachment came back as %q: %q", where, att.Name, att.Content)
+		}
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.json")
+	team := newPipelineTeam(t, newPipelineProvider())
+	// Interrupt the run after its first round, checkpointing first.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	team.Supervisor = func(ctx context.Context, t2 *Team, round []Message) {
+		if err := t2.Checkpoint(path); err != nil {
+			t.Error(err)
+		}
+		cancel()
+	}
+	if _, err := team.RunProjectRounds(ctx, 3); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want the interruption", err)
+	}
+
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Round() != 1 {
+		t.Fatalf("restored round %d, want 1", loaded.Round())
+	}
+	llm := newPipelineProvider()
+	fresh := newPipelineTeam(t, llm)
+	for i, r := range team.Roles {
+		got, want := loaded.Role(r.Name).Memory.GetRecentN(-1), r.Memory.GetRecentN(-1)
+		if !slices.EqualFunc(got, want, func(a, b Message) bool { return a.ID == b.ID && a.Content == b.Content }) {
+			t.Errorf("%s: memory %v, want %v", r.Name, causes(got), causes(want))
+		}
+		loaded.Role(r.Name).Actions = fresh.Roles[i].Actions
+	}
+	loaded.Output = io.Discard
+
+	msgs, err := loaded.Resume(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Round() != 3 || len(msgs) != 6 {
+		t.Errorf("resumed to round %d with %d messages, want round 3 and 6", loaded.Round(), len(msgs))
+	}
+	if n := len(loaded.Role("Bob").Memory.GetRecentN(-1)); n <= len(team.Role("Bob").Memory.GetRecentN(-1)) {
+		t.Errorf("tester memory did not grow past the checkpoint: %d messages", n)
+	}
+}
+
+func TestCheckpointKeepsMemorySettings(t *testing.T) {
+	msgs := []Message{
+		NewMessage(nil, "idea", "User", CauseUserRequirement),
+		NewMessage(nil, "code", "SimpleCoder", CauseWriteCode),
+	}
+	bounded := &Role{Name: "Alice", Memory: &Memory{MaxMessages: 2, DedupThreshold: 0.9, TTL: time.Hour}}
+	sharded := &Role{Name: "Bob", Memory: NewShardedMemory(3)}
+	for _, r := range []*Role{bounded, sharded} {
+		for _, msg := range msgs {
+			r.Memory.Add(msg)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "team.json")
+	if err := (&Team{Roles: []*Role{bounded, sharded}}).Checkpoint(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mem, ok := loaded.Role("Alice").Memory.(*Memory)
+	if !ok || mem.MaxMessages != 2 || mem.DedupThreshold != 0.9 || mem.TTL != time.Hour {
+		t.Fatalf("Alice's memory came back as %#v", loaded.Role("Alice").Memory)
+	}
+	mem.Add(NewMessage(nil, "tests", "SimpleTester", CauseWriteTest))
+	if got := causes(mem.GetRecentN(-1)); !slices.Equal(got, []CauseBy{CauseWriteCode, CauseWriteTest}) {
+		t.Errorf("MaxMessages not applied after loading: %v", got)
+	}
+
+	shards, ok := loaded.Role("Bob").Memory.(*ShardedMemory)
+	if !ok || len(shards.shards) != 3 {
+		t.Fatalf("Bob's memory came back as %#v", loaded.Role("Bob").Memory)
+	}
+	shards.Add(NewMessage(nil, "tests", "SimpleTester", CauseWriteTest))
+	if got := causes(shards.GetRecentN(-1)); !slices.Equal(got, []CauseBy{CauseUserRequirement, CauseWriteCode, CauseWriteTest}) {
+		t.Errorf("sharded memory after loading: %v", got)
+	}
+}
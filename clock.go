@@ -0,0 +1,25 @@
+package main
+
+import "time"
+
+// Clock abstracts the passage of time so that time-dependent behaviour can be
+// driven by a fake in tests.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// orRealClock returns c, or the wall clock if c is nil.
+func orRealClock(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
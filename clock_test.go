@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// fakeClock is a Clock that only moves when waited on: Sleep and After
+// advance it at once and record the wait.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) { c.Advance(d) }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+	return ch
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func TestFakeClockStampsMessages(t *testing.T) {
+	clock := newFakeClock()
+	coder := &Role{Name: "Alice", Profile: "Coder", Actions: []Action{echoAction{CauseWriteCode}},
+		WatchList: []CauseBy{CauseUserRequirement}, Memory: &Memory{}, Clock: clock}
+	team := &Team{Roles: []*Role{coder}, ProjectIdea: "product of a list", Clock: clock}
+	msgs, err := team.RunProjectRounds(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range append(coder.Memory.GetRecentN(-1), msgs...) {
+		if !msg.Timestamp.Equal(clock.Now()) {
+			t.Errorf("%s stamped %v, want the fake clock's %v", msg.CauseBy, msg.Timestamp, clock.Now())
+		}
+	}
+}
+
+func TestFakeClockDrivesBackoff(t *testing.T) {
+	clock := newFakeClock()
+	failures := 2
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+		if failures > 0 {
+			failures--
+			return "", errors.New("server busy")
+		}
+		return "ok", nil
+	}}
+	opts := ActionOptions{ErrorRetries: 2, Backoff: Backoff{Base: time.Hour, Jitter: NoJitter, Clock: clock}}
+
+	start := time.Now()
+	if _, err := opts.chat(context.Background(), p, CauseWriteCode, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("backoff slept on the wall clock")
+	}
+	if len(clock.waits) != 2 || clock.waits[0] != time.Hour || clock.waits[1] != 2*time.Hour {
+		t.Errorf("waited %v on the fake clock, want [1h 2h]", clock.waits)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SimpleWriteCommit writes a conventional-commit message for the code or
+// diff in its context. The reply is returned as plain text, not parsed as
+// code.
+type SimpleWriteCommit struct {
+	ActionOptions
+	llmClient LLMProvider
+}
+
+func (a *SimpleWriteCommit) Name() CauseBy { return CauseWriteCommit }
+
+func (a *SimpleWriteCommit) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleWriteCommit) Run(ctx context.Context, contextData string) (string, error) {
+	prompt := fmt.Sprintf("Context: %s\nWrite a git commit message for the code or diff above using the Conventional Commits format.\nThe first line must be `type(scope): summary` with type one of feat, fix, docs, style, refactor, perf, test, build, ci, chore or revert, at most 72 characters.\nThen a blank line and a short body explaining what changed and why.\nReturn only the commit message with NO other texts.", contextData)
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return "", err
+	}
+
+	msg := strings.TrimSpace(strings.Trim(strings.TrimSpace(content), "`"))
+	subject, _, _ := strings.Cut(msg, "\n")
+	if !conventionalSubject.MatchString(subject) {
+		return "", fmt.Errorf("commit subject %q is not in conventional-commit form", subject)
+	}
+	return a.label(a.Name(), msg), nil
+}
+
+var conventionalSubject = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([\w./-]+\))?!?: \S.{0,70}$`)
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestSimpleWriteCommit(t *testing.T) {
+	for _, tc := range []struct {
+		reply, want string
+		ok          bool
+	}{
+		{"feat(math): add product of a list\n\nMultiplies every element.", "feat(math): add product of a list\n\nMultiplies every element.", true},
+		{"```\nfix: handle empty lists\n```", "fix: handle empty lists", true},
+		{"refactor!: drop python 2 support", "refactor!: drop python 2 support", true},
+		{"Added a product function", "", false},
+		{"feature(math): add product", "", false},
+		{"feat: " + strings.Repeat("x", 80), "", false},
+	} {
+		p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return tc.reply, nil }}
+		got, err := (&SimpleWriteCommit{llmClient: p}).Run(context.Background(), sampleCode)
+		if (err == nil) != tc.ok {
+			t.Errorf("%q: err = %v, want success %v", tc.reply, err, tc.ok)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.reply, got, tc.want)
+		}
+	}
+}
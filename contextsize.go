@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrContextTooLarge is returned (wrapped) by Role.Act when an action's
+// context would not fit in its model's context window.
+var ErrContextTooLarge = errors.New("context exceeds the model's context window")
+
+// modelContextWindows lists known context sizes in tokens, matched by the
+// longest prefix of the model name.
+var modelContextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-32k":     32768,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"o1":            200000,
+	"o3":            200000,
+}
+
+// ModelContextWindow returns the context size of model in tokens, or 0 if
+// it is unknown.
+func ModelContextWindow(model string) int {
+	best, size := "", 0
+	for prefix, n := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, size = prefix, n
+		}
+	}
+	return size
+}
+
+// ModelNamer is implemented by actions that report the model they call.
+type ModelNamer interface {
+	ModelName() string
+}
+
+// checkContextSize fails if contextData alone would not fit in the window
+// of the action's model. Actions with an unknown model are not checked.
+func checkContextSize(a Action, contextData string) error {
+	m, ok := a.(ModelNamer)
+	if !ok {
+		return nil
+	}
+	window := ModelContextWindow(m.ModelName())
+	if window == 0 {
+		return nil
+	}
+	if tokens := estimateTokens(contextData); tokens > window {
+		return fmt.Errorf("%w: about %d tokens for %s (%d)", ErrContextTooLarge, tokens, m.ModelName(), window)
+	}
+	return nil
+}
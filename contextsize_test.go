@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestModelContextWindow(t *testing.T) {
+	for model, want := range map[string]int{
+		"gpt-4":               8192,
+		"gpt-4-0613":          8192,
+		"gpt-4-32k-0613":      32768,
+		"gpt-4o-mini":         128000,
+		"gpt-4.1-nano":        1047576,
+		"my-azure-deployment": 0,
+	} {
+		if got := ModelContextWindow(model); got != want {
+			t.Errorf("ModelContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestOversizedContextCaughtBeforeCall(t *testing.T) {
+	p := newPipelineProvider()
+	r := &Role{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteReview{llmClient: p, ActionOptions: ActionOptions{Model: "gpt-4"}}}}
+	r.Memory.Add(NewMessage(nil, strings.Repeat("word ", 8192), "SimpleTester", CauseWriteTest))
+
+	_, err := r.Act(context.Background())
+	if !errors.Is(err, ErrContextTooLarge) {
+		t.Fatalf("got %v, want ErrContextTooLarge", err)
+	}
+	if p.calls() != 0 {
+		t.Errorf("%d provider calls for an oversized context", p.calls())
+	}
+
+	r.Actions[0].(*SimpleWriteReview).Model = "gpt-4o"
+	if _, err := r.Act(context.Background()); err != nil {
+		t.Errorf("a larger window still rejected the context: %v", err)
+	}
+}
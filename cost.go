@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Meta keys under which Role.Act records what producing a message cost,
+// summed over all provider calls its action made.
+const (
+	metaPromptTokens     = "prompt_tokens"
+	metaCompletionTokens = "completion_tokens"
+	metaCostUSD          = "cost_usd"
+)
+
+// modelPrice is what a model charges in US dollars per million tokens.
+type modelPrice struct {
+	Prompt, Completion float64
+}
+
+// modelPrices lists list prices, matched by the longest prefix of the model
+// name like modelContextWindows.
+var modelPrices = map[string]modelPrice{
+	"gpt-3.5-turbo": {0.5, 1.5},
+	"gpt-4":         {30, 60},
+	"gpt-4-32k":     {60, 120},
+	"gpt-4-turbo":   {10, 30},
+	"gpt-4o":        {2.5, 10},
+	"gpt-4o-mini":   {0.15, 0.6},
+	"gpt-4.1":       {2, 8},
+	"o1":            {15, 60},
+	"o3":            {2, 8},
+}
+
+// EstimateCost returns the cost in US dollars of usage on model, or 0 if
+// the model's price is unknown.
+func EstimateCost(model string, usage openai.Usage) float64 {
+	best, price := "", modelPrice{}
+	for prefix, p := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best, price = prefix, p
+		}
+	}
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6
+}
+
+// recordUsage adds a provider call's usage to the message being produced.
+// It is a no-op outside Role.Act.
+func recordUsage(ctx context.Context, model string, usage openai.Usage) {
+	m, ok := ctx.Value(metaKey{}).(*messageMeta)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.PromptTokens += usage.PromptTokens
+	m.usage.CompletionTokens += usage.CompletionTokens
+	m.usage.TotalTokens += usage.TotalTokens
+	m.cost += EstimateCost(model, usage)
+}
+
+// MessageUsage returns the tokens and estimated cost recorded for msg, all
+// zero for messages not produced by an LLM call.
+func MessageUsage(msg Message) (promptTokens, completionTokens int, costUSD float64) {
+	promptTokens, _ = strconv.Atoi(msg.Meta[metaPromptTokens])
+	completionTokens, _ = strconv.Atoi(msg.Meta[metaCompletionTokens])
+	costUSD, _ = strconv.ParseFloat(msg.Meta[metaCostUSD], 64)
+	return promptTokens, completionTokens, costUSD
+}
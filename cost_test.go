@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestMessageCostAttribution(t *testing.T) {
+	calls := 0
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", nil
+		}
+		return "```python\n" + sampleCode + "\n```", nil
+	}}
+	r := &Role{Name: "Alice", Profile: "SimpleCoder", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteCode{llmClient: p, ActionOptions: ActionOptions{Model: "gpt-4o", EmptyRetries: 1}}}}
+	r.Memory.Add(NewMessage(nil, "product of a list", "User", CauseUserRequirement))
+
+	msg, err := r.Act(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two calls of 100 prompt and 50 completion tokens at $2.50 and $10
+	// per million.
+	prompt, completion, cost := MessageUsage(msg)
+	if prompt != 200 || completion != 100 {
+		t.Errorf("recorded %d prompt and %d completion tokens, want 200 and 100", prompt, completion)
+	}
+	if want := 0.0015; math.Abs(cost-want) > 1e-9 {
+		t.Errorf("recorded cost $%v, want $%v", cost, want)
+	}
+
+	if p, c, cost := MessageUsage(NewMessage(nil, "idea", "User", CauseUserRequirement)); p+c != 0 || cost != 0 {
+		t.Errorf("a message without calls has usage %d, %d, %v", p, c, cost)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	usage := openai.Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}
+	for model, want := range map[string]float64{"gpt-4o-mini-2024-07-18": 0.75, "gpt-4o": 12.5, "gpt-4": 90, "unknown": 0} {
+		if got := EstimateCost(model, usage); math.Abs(got-want) > 1e-9 {
+			t.Errorf("EstimateCost(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// CallCounter is implemented by providers that count the calls they
+// receive.
+type CallCounter interface {
+	CallCount() int
+}
+
+// CountingProvider wraps a provider and counts every CreateChatCompletion
+// call, retries and failed calls included. It is safe for concurrent use.
+type CountingProvider struct {
+	Provider LLMProvider
+
+	calls atomic.Int64
+}
+
+func (c *CountingProvider) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	c.calls.Add(1)
+	return c.Provider.CreateChatCompletion(ctx, req)
+}
+
+// CallCount returns how many calls the provider has received.
+func (c *CountingProvider) CallCount() int { return int(c.calls.Load()) }
+
+// Name returns the name of the wrapped provider.
+func (c *CountingProvider) Name() string { return providerName(c.Provider) }
+
+// CallCount returns how many calls the provider has received.
+func (c *ChaosProvider) CallCount() int { return c.Calls() }
+
+// llmAction is implemented by the actions that call a provider.
+type llmAction interface {
+	provider() LLMProvider
+}
+
+// CallCount returns the total of the counting providers, such as
+// CountingProvider, behind the actions and summarizers of the team, its
+// integrator and its sub-teams. A provider shared by several actions is
+// counted once. Providers that do not count are skipped.
+func (t *Team) CallCount() int {
+	counters := make(map[CallCounter]bool)
+	t.collectCounters(counters)
+	total := 0
+	for c := range counters {
+		total += c.CallCount()
+	}
+	return total
+}
+
+func (t *Team) collectCounters(counters map[CallCounter]bool) {
+	roles := t.roles()
+	if t.Integrator != nil {
+		roles = append(roles, t.Integrator)
+	}
+	for _, r := range roles {
+		actions := r.Actions
+		if r.Summarizer != nil {
+			actions = append(actions[:len(actions):len(actions)], r.Summarizer)
+		}
+		for _, a := range actions {
+			if la, ok := a.(llmAction); ok {
+				if c, ok := la.provider().(CallCounter); ok {
+					counters[c] = true
+				}
+			}
+		}
+	}
+	for _, sub := range t.SubTeams {
+		sub.collectCounters(counters)
+	}
+}
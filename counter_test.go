@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestCallCountUnderConcurrentRoles(t *testing.T) {
+	const coders = 8
+	counted := &CountingProvider{Provider: &ChaosProvider{Provider: newPipelineProvider(), FailureFunc: FailCalls(2, 5)}}
+	team := &Team{ProjectIdea: "write a function that returns the product of a list", Output: io.Discard}
+	for i := 0; i < coders; i++ {
+		team.Roles = append(team.Roles, &Role{Name: "Alice", Profile: "SimpleCoder", Memory: &Memory{},
+			Actions:   []Action{&SimpleWriteCode{llmClient: counted, ActionOptions: ActionOptions{ErrorRetries: 1}}},
+			WatchList: []CauseBy{CauseUserRequirement}})
+	}
+	other := &CountingProvider{Provider: newPipelineProvider()}
+	sub := &Team{Output: io.Discard, Roles: []*Role{{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteReview{llmClient: other}}, WatchList: []CauseBy{CauseUserRequirement}}}}
+	team.SubTeams = []*Team{sub}
+
+	msgs, err := team.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != coders+1 {
+		t.Fatalf("got %d messages, want %d", len(msgs), coders+1)
+	}
+	if n := counted.CallCount(); n != coders+2 {
+		t.Errorf("counted %d calls, want %d: one per coder and the two retries", n, coders+2)
+	}
+	if n := team.CallCount(); n != coders+3 {
+		t.Errorf("team counted %d calls, want %d", n, coders+3)
+	}
+}
+
+func TestCountingProviderIsConcurrencySafe(t *testing.T) {
+	counted := &CountingProvider{Provider: newPipelineProvider()}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			(ActionOptions{}).chat(context.Background(), counted, CauseWriteReview, "review")
+		}()
+	}
+	wg.Wait()
+	if n := counted.CallCount(); n != 50 {
+		t.Errorf("counted %d calls, want 50", n)
+	}
+}
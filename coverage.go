@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CoverageCheck runs the latest generated tests against the latest generated
+// code under pytest-cov and reports the total line coverage. It reads the
+// code and tests from the SimpleWriteCode and SimpleWriteTest messages in
+// memory, so it always receives the whole history. It only runs when
+// Team.AllowExecution is set.
+type CoverageCheck struct {
+	// MinCoverage, if positive, is the percentage the report is compared
+	// against; falling short is noted in the output rather than failing.
+	MinCoverage float64
+}
+
+func (a *CoverageCheck) Name() CauseBy { return CauseCoverageCheck }
+
+func (a *CoverageCheck) ContextWindow() int { return -1 }
+
+func (a *CoverageCheck) Run(ctx context.Context, contextData string) (string, error) {
+	return "", fmt.Errorf("%s needs the message history; run it through Role.Act", a.Name())
+}
+
+func (a *CoverageCheck) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	code, tests := latestContent(msgs, CauseWriteCode), latestContent(msgs, CauseWriteTest)
+	if code == "" || tests == "" {
+		return "", fmt.Errorf("%s: no code or tests in memory yet", a.Name())
+	}
+
+	if err := checkExecution(ctx); err != nil {
+		return "", fmt.Errorf("%s: %w", a.Name(), err)
+	}
+	pct, err := runCoverage(ctx, code, tests)
+	if err != nil {
+		return "", err
+	}
+
+	out := fmt.Sprintf("coverage: %.0f%%", pct)
+	if a.MinCoverage > 0 && pct < a.MinCoverage {
+		out += fmt.Sprintf(" (below required %.0f%%)", a.MinCoverage)
+	}
+	return out, nil
+}
+
+// latestContent returns the content of the newest message caused by causeBy.
+func latestContent(msgs []Message, causeBy CauseBy) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].CauseBy == causeBy {
+			return msgs[i].Content
+		}
+	}
+	return ""
+}
+
+var coverageTotal = regexp.MustCompile(`(?m)^TOTAL\s.*?(\d+(?:\.\d+)?)%\s*$`)
+
+func runCoverage(ctx context.Context, code, tests string) (float64, error) {
+	// pytest exits non-zero when tests fail; the coverage table is still
+	// printed, so only a missing table is treated as an error.
+	out, _, err := runPytest(ctx, code, tests, "-q", "--cov=solution", "--cov-report=term")
+	if err != nil {
+		return 0, err
+	}
+
+	m := coverageTotal.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("coverage check: no coverage total in pytest output:\n%s", strings.TrimSpace(out))
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
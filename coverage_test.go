@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const coverageReport = `..                                                  [100%]
+
+---------- coverage: platform linux, python 3.12.3-final-0 -----------
+Name          Stmts   Miss  Cover
+---------------------------------
+solution.py       6      2    67%
+---------------------------------
+TOTAL             6      2    67%
+`
+
+func coverageHistory() []Message {
+	return []Message{
+		NewMessage(nil, sampleCode, "SimpleCoder", CauseWriteCode),
+		NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest),
+	}
+}
+
+func TestCoverageCheck(t *testing.T) {
+	fakeInterpreter(t, "cat <<'EOF'\n"+coverageReport+"EOF")
+	ctx := withExecutionPolicy(context.Background(), true, nil)
+
+	for _, tc := range []struct {
+		min  float64
+		want string
+	}{
+		{0, "coverage: 67%"},
+		{80, "coverage: 67% (below required 80%)"},
+		{60, "coverage: 67%"},
+	} {
+		got, err := (&CoverageCheck{MinCoverage: tc.min}).RunMessages(ctx, coverageHistory())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("MinCoverage %v: got %q, want %q", tc.min, got, tc.want)
+		}
+	}
+}
+
+func TestCoverageCheckWithoutTotal(t *testing.T) {
+	fakeInterpreter(t, "echo 'no tests ran'")
+	ctx := withExecutionPolicy(context.Background(), true, nil)
+	_, err := (&CoverageCheck{}).RunMessages(ctx, coverageHistory())
+	if err == nil || !strings.Contains(err.Error(), "no coverage total") {
+		t.Errorf("got %v, want a missing-total error", err)
+	}
+}
+
+func TestCoverageCheckNeedsCodeAndExecution(t *testing.T) {
+	fakeInterpreter(t, "cat <<'EOF'\n"+coverageReport+"EOF")
+	if _, err := (&CoverageCheck{}).RunMessages(withExecutionPolicy(context.Background(), true, nil), coverageHistory()[:1]); err == nil {
+		t.Error("ran without tests in memory")
+	}
+	if _, err := (&CoverageCheck{}).RunMessages(context.Background(), coverageHistory()); !errors.Is(err, ErrExecutionNotAllowed) {
+		t.Errorf("got %v, want ErrExecutionNotAllowed", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotApproved is returned by CriticLoop when the reviewer has not
+// approved within the allowed iterations.
+var ErrNotApproved = errors.New("reviewer did not approve")
+
+// defaultCriticIterations bounds CriticLoop when maxIters is not positive.
+const defaultCriticIterations = 3
+
+// CriticLoop alternates coder and reviewer: the coder writes or revises,
+// the reviewer critiques, and each sees the other's latest message. It
+// stops when ParseVerdict reads an approval from the review, or fails with
+// ErrNotApproved after maxIters rounds (default 3). The coder's memory
+// should already hold the requirement. All messages produced are returned,
+// in order, also on error.
+func CriticLoop(ctx context.Context, coder, reviewer *Role, maxIters int) ([]Message, error) {
+	if maxIters <= 0 {
+		maxIters = defaultCriticIterations
+	}
+
+	var msgs []Message
+	for i := 0; i < maxIters; i++ {
+		code, err := coder.Act(ctx)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, code)
+		reviewer.Memory.Add(code)
+
+		review, err := reviewer.Act(ctx)
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, review)
+		if approve, ok := ParseVerdict(review.Content); ok && approve {
+			return msgs, nil
+		}
+		coder.Memory.Add(review)
+	}
+	return msgs, fmt.Errorf("%w after %d iterations", ErrNotApproved, maxIters)
+}
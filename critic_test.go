@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// criticPair returns a preset coder and reviewer whose reviewer rejects the
+// first rejections reviews, marking each with its number, and then
+// approves.
+func criticPair(t *testing.T, rejections int) (coder, reviewer *Role, llm *mockProvider) {
+	t.Helper()
+	reviews := 0
+	llm = &mockProvider{reply: func(req openai.ChatCompletionRequest) (string, error) {
+		if strings.Contains(req.Messages[len(req.Messages)-1].Content, "Write a python function") {
+			return "```python\n" + sampleCode + "\n```", nil
+		}
+		reviews++
+		if reviews > rejections {
+			return "Looks good.\nVERDICT: APPROVE", nil
+		}
+		return fmt.Sprintf("Review %d: handle empty lists.\nVERDICT: REJECT", reviews), nil
+	}}
+	coder, err := PresetRole("Coder", llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviewer, err = PresetRole("Reviewer", llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coder.Memory.Add(NewMessage(nil, "write a function that returns the product of a list", "Human", CauseUserRequirement))
+	return coder, reviewer, llm
+}
+
+func TestCriticLoopStopsOnApproval(t *testing.T) {
+	coder, reviewer, llm := criticPair(t, 1)
+	msgs, err := CriticLoop(context.Background(), coder, reviewer, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CauseBy{CauseWriteCode, CauseWriteReview, CauseWriteCode, CauseWriteReview}
+	if got := causes(msgs); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if llm.calls() != 4 {
+		t.Errorf("%d calls, want 4", llm.calls())
+	}
+	if revision := llm.prompts()[2]; !strings.Contains(revision, "Review 1") {
+		t.Errorf("the revision did not see the critique:\n%s", revision)
+	}
+}
+
+func TestCriticLoopStopsAtCap(t *testing.T) {
+	for _, tc := range []struct {
+		maxIters, iterations int
+	}{
+		{2, 2},
+		{0, defaultCriticIterations},
+	} {
+		coder, reviewer, _ := criticPair(t, 100)
+		msgs, err := CriticLoop(context.Background(), coder, reviewer, tc.maxIters)
+		if !errors.Is(err, ErrNotApproved) {
+			t.Fatalf("maxIters %d: got %v, want ErrNotApproved", tc.maxIters, err)
+		}
+		if len(msgs) != 2*tc.iterations {
+			t.Errorf("maxIters %d: got %d messages, want %d", tc.maxIters, len(msgs), 2*tc.iterations)
+		}
+	}
+}
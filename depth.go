@@ -0,0 +1,47 @@
+package main
+
+import "sort"
+
+// CauseDepths returns how far each cause is from the project idea in the
+// watch graph of roles: "UserRequirement" is 0 and a role's output is one
+// deeper than the shallowest cause it watches. Causes no role reaches from
+// the idea are missing.
+func CauseDepths(roles []*Role) map[CauseBy]int {
+	depths := map[CauseBy]int{CauseUserRequirement: 0}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range roles {
+			best := -1
+			for _, watch := range r.WatchList {
+				if d, ok := depths[watch]; ok && (best < 0 || d < best) {
+					best = d
+				}
+			}
+			if best < 0 {
+				continue
+			}
+			for _, a := range r.Actions {
+				for _, cause := range producedCauses(a) {
+					if d, ok := depths[cause]; !ok || best+1 < d {
+						depths[cause] = best + 1
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return depths
+}
+
+// SortByDepth returns msgs ordered by the depth of their cause, keeping the
+// original order within a depth, so that a round's transcript reads from
+// idea to review regardless of which goroutine finished first. Messages
+// of unknown causes count as depth 0.
+func SortByDepth(msgs []Message, roles []*Role) []Message {
+	depths := CauseDepths(roles)
+	out := append([]Message(nil), msgs...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return depths[out[i].CauseBy] < depths[out[j].CauseBy]
+	})
+	return out
+}
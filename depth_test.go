@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestCauseDepthsSamplePipeline(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	depths := CauseDepths(team.Roles)
+	for cause, want := range map[CauseBy]int{
+		CauseUserRequirement: 0,
+		CauseWriteCode:       1,
+		CauseWriteTest:       2,
+		CauseWriteReview:     3,
+	} {
+		if got, ok := depths[cause]; !ok || got != want {
+			t.Errorf("depth of %s = %d (known %v), want %d", cause, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describe returns a mermaid flowchart of the team's pipeline: a node per
+// role, plus "User" for the project idea, and an edge labelled with the
+// cause from every producer to each role watching it.
+func (t *Team) Describe() string {
+	roles := t.roles()
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	b.WriteString("    user([User])\n")
+	producers := map[CauseBy][]string{CauseUserRequirement: {"user"}}
+	for i, r := range roles {
+		id := fmt.Sprintf("r%d", i)
+		fmt.Fprintf(&b, "    %s[\"%s (%s)\"]\n", id, mermaidLabel(r.Name), mermaidLabel(r.Profile))
+		for _, a := range r.Actions {
+			for _, cause := range producedCauses(a) {
+				producers[cause] = append(producers[cause], id)
+			}
+		}
+	}
+
+	for i, r := range roles {
+		for _, cause := range r.WatchList {
+			for _, from := range producers[cause] {
+				fmt.Fprintf(&b, "    %s -->|%s| r%d\n", from, mermaidLabel(string(cause)), i)
+			}
+		}
+	}
+	return b.String()
+}
+
+// mermaidLabel escapes the characters mermaid treats specially in labels.
+func mermaidLabel(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	got := team.Describe()
+
+	for _, want := range []string{
+		"flowchart LR\n",
+		`r0["Alice (SimpleCoder)"]`,
+		`r1["Bob (SimpleTester)"]`,
+		`r2["Charlie (SimpleReviewer)"]`,
+		"user -->|UserRequirement| r0\n",
+		"r0 -->|SimpleWriteCode| r1\n",
+		"r1 -->|SimpleWriteTest| r2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("diagram lacks %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "-->"); n != 3 {
+		t.Errorf("diagram has %d edges, want 3:\n%s", n, got)
+	}
+}
+
+func TestDescribeEscapesLabels(t *testing.T) {
+	team := &Team{Roles: []*Role{{Name: `Dr. "Q"`, Profile: "a|b", Actions: []Action{&captureAction{}}, WatchList: []CauseBy{CauseUserRequirement}}}}
+	got := team.Describe()
+	if !strings.Contains(got, `r0["Dr. #quot;Q#quot; (a#124;b)"]`) {
+		t.Errorf("labels not escaped:\n%s", got)
+	}
+}
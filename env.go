@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Environment variables supplying defaults for actions that leave
+// Temperature or MaxTokens unset.
+const (
+	envTemperature = "LLM_TEMPERATURE"
+	envMaxTokens   = "LLM_MAX_TOKENS"
+)
+
+// Ptr returns a pointer to v, for the optional settings such as
+// ActionOptions.Temperature: Temperature: Ptr(float32(0)).
+func Ptr[T any](v T) *T { return &v }
+
+// samplingDefaults are the environment's defaults; nil fields are unset.
+type samplingDefaults struct {
+	temperature *float32
+	maxTokens   *int
+}
+
+// parseLLMEnv reads LLM_TEMPERATURE (0 to 2) and LLM_MAX_TOKENS (a
+// positive integer) through getenv. Malformed or out-of-range values are
+// an error.
+func parseLLMEnv(getenv func(string) string) (samplingDefaults, error) {
+	var d samplingDefaults
+	if v := getenv(envTemperature); v != "" {
+		t, err := strconv.ParseFloat(v, 32)
+		if err != nil || t < 0 || t > 2 {
+			return samplingDefaults{}, fmt.Errorf("%s=%q: want a number from 0 to 2", envTemperature, v)
+		}
+		d.temperature = Ptr(float32(t))
+	}
+	if v := getenv(envMaxTokens); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return samplingDefaults{}, fmt.Errorf("%s=%q: want a positive integer", envMaxTokens, v)
+		}
+		d.maxTokens = Ptr(n)
+	}
+	return d, nil
+}
+
+// llmEnv reads the environment's defaults once; main calls it at startup
+// to reject bad values before any work is done.
+var llmEnv = sync.OnceValues(func() (samplingDefaults, error) {
+	return parseLLMEnv(os.Getenv)
+})
+
+// sampling returns the temperature and token limit for a request: the
+// action's own, where set, else the environment defaults. Nil means unset,
+// leaving the model default.
+func (o ActionOptions) sampling() (temperature *float32, maxTokens *int, err error) {
+	d, err := llmEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	temperature, maxTokens = o.samplingWith(d)
+	return temperature, maxTokens, nil
+}
+
+func (o ActionOptions) samplingWith(d samplingDefaults) (temperature *float32, maxTokens *int) {
+	temperature, maxTokens = o.Temperature, o.MaxTokens
+	if temperature == nil {
+		temperature = d.temperature
+	}
+	if maxTokens == nil {
+		maxTokens = d.maxTokens
+	}
+	return temperature, maxTokens
+}
+
+// requestTemperature is t as sent in a request. go-openai omits a zero
+// temperature, which would leave the model default, so an explicit zero is
+// sent as the smallest positive float instead.
+func requestTemperature(t *float32) float32 {
+	if t == nil {
+		return 0
+	}
+	if *t == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return *t
+}
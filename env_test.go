@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func envOf(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestParseLLMEnv(t *testing.T) {
+	d, err := parseLLMEnv(envOf(map[string]string{envTemperature: "0.7", envMaxTokens: "256"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.temperature == nil || *d.temperature != 0.7 || d.maxTokens == nil || *d.maxTokens != 256 {
+		t.Fatalf("got %+v", d)
+	}
+
+	d, err = parseLLMEnv(envOf(nil))
+	if err != nil || d.temperature != nil || d.maxTokens != nil {
+		t.Fatalf("empty environment: got %+v, %v", d, err)
+	}
+
+	for _, vars := range []map[string]string{
+		{envTemperature: "2.5"},
+		{envTemperature: "-0.1"},
+		{envTemperature: "warm"},
+		{envMaxTokens: "0"},
+		{envMaxTokens: "many"},
+	} {
+		if _, err := parseLLMEnv(envOf(vars)); err == nil {
+			t.Errorf("%v accepted", vars)
+		}
+	}
+}
+
+func TestSamplingPrecedence(t *testing.T) {
+	env := samplingDefaults{temperature: Ptr(float32(0.9)), maxTokens: Ptr(100)}
+
+	temp, max := ActionOptions{}.samplingWith(env)
+	if *temp != 0.9 || *max != 100 {
+		t.Errorf("unset options: got %v, %v; want the environment's", *temp, *max)
+	}
+
+	temp, max = ActionOptions{Temperature: Ptr(float32(0)), MaxTokens: Ptr(5)}.samplingWith(env)
+	if *temp != 0 || *max != 5 {
+		t.Errorf("explicit options: got %v, %v; want 0 and 5", *temp, *max)
+	}
+
+	temp, max = ActionOptions{}.samplingWith(samplingDefaults{})
+	if temp != nil || max != nil {
+		t.Errorf("no defaults: got %v, %v; want unset", temp, max)
+	}
+}
+
+func TestExplicitZeroTemperatureIsSent(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "ok", nil }}
+	opts := ActionOptions{Temperature: Ptr(float32(0))}
+	if _, err := opts.chat(context.Background(), p, CauseWriteCode, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.requests[0].Temperature; got != math.SmallestNonzeroFloat32 {
+		t.Errorf("temperature 0 sent as %v, which go-openai omits", got)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Estimate is the size SimpleEstimate gives a project idea, one of "S",
+// "M", "L" and "XL", with the model's reasons.
+type Estimate struct {
+	Size      string
+	Rationale string
+}
+
+// Rounds suggests how many rounds to run for a project of the estimate's
+// size, e.g. for RunProjectRounds.
+func (e Estimate) Rounds() int {
+	switch e.Size {
+	case "XL":
+		return 5
+	case "L":
+		return 3
+	case "M":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ErrNoEstimate is returned (wrapped) when a reply names no size.
+var ErrNoEstimate = errors.New("no size estimate")
+
+var (
+	// estimateLabel finds a size named after a "size", "complexity" or
+	// "estimate" label, in any case and markdown emphasis.
+	estimateLabel = regexp.MustCompile(`(?i)\b(?:t-shirt size|size|complexity|estimate)\b[^\w\n]{0,10}(x-?large|extra[- ]large|xl|small|medium|large|s|m|l)\b`)
+	// estimateBare finds a size standing on its own, upper case only so
+	// that words like "a" or "I" do not count.
+	estimateBare  = regexp.MustCompile(`(?:^|[^\w-])(XL|S|M|L)(?:$|[^\w-])`)
+	rationaleLine = regexp.MustCompile(`(?ims)^[^\w\n]*(?:rationale|reasoning|reasons?)\b[^\w\n]*(.*)`)
+)
+
+var estimateSizes = map[string]string{
+	"s": "S", "small": "S",
+	"m": "M", "medium": "M",
+	"l": "L", "large": "L",
+	"xl": "XL", "x-large": "XL", "xlarge": "XL", "extra large": "XL", "extra-large": "XL",
+}
+
+// ParseEstimate reads the size and rationale from a model reply. The size
+// is taken from a labelled mention such as "Complexity: **Large**" or, if
+// there is none, from the first standalone S, M, L or XL. The rationale is
+// what follows a "Rationale:" line label, or else the whole reply.
+func ParseEstimate(reply string) (Estimate, error) {
+	var size string
+	if m := estimateLabel.FindStringSubmatch(reply); m != nil {
+		size = estimateSizes[strings.ToLower(m[1])]
+	} else if m := estimateBare.FindStringSubmatch(reply); m != nil {
+		size = m[1]
+	}
+	if size == "" {
+		return Estimate{}, fmt.Errorf("%w in %q", ErrNoEstimate, reply)
+	}
+
+	rationale := strings.TrimSpace(reply)
+	if m := rationaleLine.FindStringSubmatch(reply); m != nil && strings.TrimSpace(m[1]) != "" {
+		rationale = strings.TrimSpace(m[1])
+	}
+	return Estimate{Size: size, Rationale: rationale}, nil
+}
+
+// metaEstimateSize is the Message.Meta key holding SimpleEstimate's size.
+const metaEstimateSize = "estimate_size"
+
+// SimpleEstimate estimates the complexity of the project idea in its
+// context. Its output is "Size: <size>" followed by the rationale, and the
+// size is also recorded in the message's Meta under "estimate_size". A
+// reply without a size is retried once.
+type SimpleEstimate struct {
+	ActionOptions
+	llmClient LLMProvider
+}
+
+func (a *SimpleEstimate) Name() CauseBy { return CauseEstimate }
+
+func (a *SimpleEstimate) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleEstimate) Run(ctx context.Context, contextData string) (string, error) {
+	est, err := a.Estimate(ctx, contextData)
+	if err != nil {
+		return "", err
+	}
+	setMeta(ctx, metaEstimateSize, est.Size)
+	return a.label(a.Name(), fmt.Sprintf("Size: %s\nRationale: %s", est.Size, est.Rationale)), nil
+}
+
+// Estimate asks the model for an estimate and returns it parsed.
+func (a *SimpleEstimate) Estimate(ctx context.Context, contextData string) (Estimate, error) {
+	prompt := fmt.Sprintf("Context: %s\nEstimate the effort to implement the idea above as a t-shirt size: S, M, L or XL.\nReply in the form:\nSize: <S|M|L|XL>\nRationale: <one short paragraph>", contextData)
+
+	for attempt := 0; ; attempt++ {
+		content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+		if err != nil {
+			return Estimate{}, err
+		}
+		est, err := ParseEstimate(content)
+		if err == nil || attempt >= 1 || !allowRetry(ctx) {
+			return est, err
+		}
+		prompt = fmt.Sprintf("%s\nYour previous answer named no size; start it with \"Size: \" and one of S, M, L or XL.", prompt)
+	}
+}
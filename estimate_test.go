@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const verboseEstimate = `Great question! Let me think about this carefully.
+
+A product-of-a-list function is a small, well-understood task, but we should
+also care about empty lists, large integers and a test suite.
+
+**Complexity:** *Medium*
+
+**Rationale:** the core is a single loop, yet the edge cases and the tests
+double the work.
+
+Let me know if you want a breakdown!`
+
+func TestParseEstimate(t *testing.T) {
+	for _, tc := range []struct {
+		name, reply, size string
+	}{
+		{"verbose", verboseEstimate, "M"},
+		{"labelled", "Size: XL\nRationale: a whole platform.", "XL"},
+		{"lower case word", "estimate - large, because of the integrations", "L"},
+		{"spelled out", "T-shirt size: extra large", "XL"},
+		{"x-large", "Complexity = X-Large", "XL"},
+		{"bare", "I would call it S. It is a one-liner.", "S"},
+		{"bare in markdown", "My answer: **L**", "L"},
+	} {
+		est, err := ParseEstimate(tc.reply)
+		if err != nil || est.Size != tc.size {
+			t.Errorf("%s: got %+v, %v, want size %s", tc.name, est, err, tc.size)
+		}
+	}
+
+	est, _ := ParseEstimate(verboseEstimate)
+	if !strings.HasPrefix(est.Rationale, "the core is a single loop") {
+		t.Errorf("rationale %q", est.Rationale)
+	}
+	if est, _ := ParseEstimate("Size: S"); est.Rationale != "Size: S" {
+		t.Errorf("without a rationale label it should be the reply, got %q", est.Rationale)
+	}
+	if _, err := ParseEstimate("It depends on a lot of things."); !errors.Is(err, ErrNoEstimate) {
+		t.Errorf("got %v, want ErrNoEstimate", err)
+	}
+}
+
+func TestSimpleEstimate(t *testing.T) {
+	replies := []string{"I cannot say without more details.", verboseEstimate}
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+		r := replies[0]
+		replies = replies[1:]
+		return r, nil
+	}}
+	r := &Role{Name: "Pat", Profile: "Estimator", Memory: &Memory{}, Actions: []Action{&SimpleEstimate{llmClient: p}}}
+	r.Memory.Add(NewMessage(nil, "write a function that returns the product of a list", "User", CauseUserRequirement))
+	msg, err := r.Act(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.calls() != 2 || !strings.Contains(p.prompts()[1], "named no size") {
+		t.Errorf("%d calls; the reply without a size was not retried with a hint", p.calls())
+	}
+	if msg.Meta[metaEstimateSize] != "M" || !strings.HasPrefix(msg.Content, "Size: M\nRationale: the core") {
+		t.Errorf("got %q with meta %v", msg.Content, msg.Meta)
+	}
+	if (Estimate{Size: "M"}).Rounds() != 2 || (Estimate{Size: "XL"}).Rounds() != 5 {
+		t.Error("Rounds does not grow with the size")
+	}
+}
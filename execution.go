@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrExecutionNotAllowed is returned by actions that run generated code
+// when the run does not allow execution or the confirmation was declined.
+var ErrExecutionNotAllowed = errors.New("execution of generated code not allowed")
+
+type executionPolicy struct {
+	allow   bool
+	confirm func() bool
+}
+
+type executionKey struct{}
+
+// withExecutionPolicy records whether generated code may be executed under
+// ctx, unless ctx already carries a policy (sub-teams follow their
+// parent's).
+func withExecutionPolicy(ctx context.Context, allow bool, confirm func() bool) context.Context {
+	if ctx.Value(executionKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, executionKey{}, executionPolicy{allow, confirm})
+}
+
+// checkExecution is called before each execution of generated code. It
+// fails unless the run allows execution and, if a confirmation hook is set,
+// the hook approves this execution. Without a policy nothing is executed.
+func checkExecution(ctx context.Context) error {
+	p, _ := ctx.Value(executionKey{}).(executionPolicy)
+	if !p.allow {
+		return ErrExecutionNotAllowed
+	}
+	if p.confirm != nil && !p.confirm() {
+		return fmt.Errorf("%w: declined", ErrExecutionNotAllowed)
+	}
+	return nil
+}
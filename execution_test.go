@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExecutionGate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		allow   bool
+		confirm func() bool
+		runs    bool
+	}{
+		{"default", false, nil, false},
+		{"confirmation ignored without AllowExecution", false, func() bool { return true }, false},
+		{"declined", true, func() bool { return false }, false},
+		{"allowed", true, nil, true},
+		{"confirmed", true, func() bool { return true }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "ran")
+			fakeInterpreter(t, "touch "+marker+"; echo '1 passed in 0.01s'")
+
+			team := newPipelineTeam(t, newPipelineProvider())
+			team.AddRole(&Role{Name: "Runner", Profile: "TestRunner", Actions: []Action{&RunTests{}}, WatchList: []CauseBy{CauseWriteCode, CauseWriteTest}})
+			team.AllowExecution = tc.allow
+			asked := 0
+			if tc.confirm != nil {
+				team.ConfirmExecution = func() bool { asked++; return tc.confirm() }
+			}
+
+			msgs, _ := team.RunProject(context.Background())
+			_, err := os.Stat(marker)
+			if ran := err == nil; ran != tc.runs {
+				t.Errorf("code executed: %v, want %v", ran, tc.runs)
+			}
+			if observed := slices.Contains(causes(msgs), ObservationCause); observed != tc.runs {
+				t.Errorf("test run reported: %v, want %v", observed, tc.runs)
+			}
+			if tc.allow && tc.confirm != nil && asked != 1 {
+				t.Errorf("confirmation asked %d times, want once", asked)
+			}
+			if !tc.allow && asked != 0 {
+				t.Errorf("confirmation asked without AllowExecution")
+			}
+		})
+	}
+}
+
+func TestCheckExecutionWithoutPolicy(t *testing.T) {
+	if err := checkExecution(context.Background()); !errors.Is(err, ErrExecutionNotAllowed) {
+		t.Errorf("got %v, want ErrExecutionNotAllowed", err)
+	}
+	ctx := withExecutionPolicy(context.Background(), true, func() bool { return false })
+	if err := checkExecution(ctx); !errors.Is(err, ErrExecutionNotAllowed) {
+		t.Errorf("declined: got %v, want ErrExecutionNotAllowed", err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// codeCauses are the actions whose whole output is source code.
+var codeCauses = map[CauseBy]bool{
+	CauseWriteCode: true,
+	CauseWriteTest: true,
+}
+
+var exportTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.msg { border: 1px solid #ddd; border-radius: 6px; margin: 1rem 0; padding: 0 1rem 1rem; }
+.msg h2 { font-size: 1.05rem; margin: .8rem 0 .4rem; }
+.meta { color: #777; font-size: .85rem; }
+.prose { white-space: pre-wrap; }
+pre { background: #f6f8fa; padding: .8rem; overflow-x: auto; border-radius: 4px; }
+.kw { color: #d73a49; font-weight: bold; }
+.str { color: #032f62; }
+.com { color: #6a737d; font-style: italic; }
+.notice { color: #777; font-style: italic; }
+.attachment h3 { font-size: .95rem; margin: .8rem 0 .2rem; font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Notice}}<p class="notice">{{.Notice}}</p>
+{{end}}{{range .Messages}}<div class="msg">
+<h2>{{.Role}}</h2>
+<div class="meta">{{.CauseBy}}{{if .Time}} &middot; {{.Time}}{{end}}{{if .Cost}} &middot; {{.Cost}}{{end}}</div>
+{{.Body}}
+{{range .Attachments}}<div class="attachment">
+<h3>{{.Name}}</h3>
+{{.Body}}
+</div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+type exportMessage struct {
+	Role, CauseBy, Time, Cost string
+	Body                      template.HTML
+	Attachments               []exportAttachment
+}
+
+type exportAttachment struct {
+	Name string
+	Body template.HTML
+}
+
+// ExportOptions caps the size of an export and picks its order. The oldest
+// messages are left out first, and the export says how many were. Zero
+// means no limit.
+type ExportOptions struct {
+	// MaxMessages is the number of messages kept.
+	MaxMessages int
+	// MaxBytes bounds the total size of the kept messages' content.
+	MaxBytes int
+	// ByDepth orders the messages with SortByDepth instead of by time,
+	// so the export reads from idea to review.
+	ByDepth bool
+}
+
+// messages returns t's transcript in the order opts asks for.
+func (o ExportOptions) messages(t *Team) []Message {
+	msgs := t.Transcript()
+	if o.ByDepth {
+		msgs = SortByDepth(msgs, t.roles())
+	}
+	return msgs
+}
+
+// limit returns the newest messages of msgs within the options' caps and a
+// notice naming how many were left out, empty if none were.
+func (o ExportOptions) limit(msgs []Message) (kept []Message, notice string) {
+	start := 0
+	if o.MaxMessages > 0 && len(msgs) > o.MaxMessages {
+		start = len(msgs) - o.MaxMessages
+	}
+	if o.MaxBytes > 0 {
+		size, i := 0, len(msgs)
+		for i > start && size+len(msgs[i-1].Content) <= o.MaxBytes {
+			size += len(msgs[i-1].Content)
+			i--
+		}
+		start = i
+	}
+	if start == 0 {
+		return msgs, ""
+	}
+	return msgs[start:], fmt.Sprintf("%d earlier messages of %d omitted", start, len(msgs))
+}
+
+// ExportHTML writes the transcript as a self-contained HTML page with role
+// headings, timestamps, token costs and highlighted code blocks, within the
+// caps of opts. All content is escaped.
+func (t *Team) ExportHTML(w io.Writer, opts ExportOptions) error {
+	return writeHTML(w, t.ProjectIdea, opts.messages(t), opts)
+}
+
+// ExportJSON writes the transcript as a JSON object holding the title, the
+// messages and, if opts left any out, a notice saying how many.
+func (t *Team) ExportJSON(w io.Writer, opts ExportOptions) error {
+	msgs, notice := opts.limit(opts.messages(t))
+	if msgs == nil {
+		msgs = []Message{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Title    string    `json:"title"`
+		Notice   string    `json:"notice,omitempty"`
+		Messages []Message `json:"messages"`
+	}{Title: t.ProjectIdea, Notice: notice, Messages: msgs})
+}
+
+func writeHTML(w io.Writer, title string, msgs []Message, opts ExportOptions) error {
+	data := struct {
+		Title, Notice string
+		Messages      []exportMessage
+	}{Title: title}
+	if data.Title == "" {
+		data.Title = "Transcript"
+	}
+	msgs, data.Notice = opts.limit(msgs)
+
+	for _, msg := range msgs {
+		em := exportMessage{Role: msg.Role, CauseBy: string(msg.CauseBy)}
+		if !msg.Timestamp.IsZero() {
+			em.Time = msg.Timestamp.Format(time.RFC3339)
+		}
+		if prompt, completion, cost := MessageUsage(msg); prompt+completion > 0 {
+			em.Cost = fmt.Sprintf("%d in, %d out tokens, $%.4f", prompt, completion, cost)
+		}
+		if codeCauses[msg.CauseBy] {
+			em.Body = codeBlock(msg.Content)
+		} else {
+			em.Body = renderProse(msg.Content)
+		}
+		for _, att := range msg.Attachments {
+			em.Attachments = append(em.Attachments, exportAttachment{Name: att.Name, Body: attachmentBlock(att)})
+		}
+		data.Messages = append(data.Messages, em)
+	}
+	return exportTemplate.Execute(w, data)
+}
+
+var fencedBlock = regexp.MustCompile("(?s)```[\\w+-]*[ \\t]*\\n(.*?)```")
+
+// renderProse escapes text, turning fenced code blocks into highlighted
+// <pre> blocks.
+func renderProse(s string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range fencedBlock.FindAllStringSubmatchIndex(s, -1) {
+		if text := strings.TrimSpace(s[last:m[0]]); text != "" {
+			b.WriteString(`<div class="prose">` + html.EscapeString(text) + "</div>\n")
+		}
+		b.WriteString(string(codeBlock(s[m[2]:m[3]])))
+		last = m[1]
+	}
+	if text := strings.TrimSpace(s[last:]); text != "" {
+		b.WriteString(`<div class="prose">` + html.EscapeString(text) + "</div>\n")
+	}
+	return template.HTML(b.String())
+}
+
+var pythonToken = regexp.MustCompile(`(#[^\n]*)|("""(?s:.*?)"""|'''(?s:.*?)'''|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*')|\b(def|class|return|if|elif|else|for|while|in|not|and|or|is|import|from|as|with|try|except|finally|raise|assert|lambda|yield|pass|break|continue|None|True|False)\b`)
+
+// attachmentBlock renders an attachment's content as a code block, with
+// Python highlighting for .py files.
+func attachmentBlock(att Attachment) template.HTML {
+	if strings.HasSuffix(att.Name, ".py") {
+		return codeBlock(string(att.Content))
+	}
+	return template.HTML("<pre><code>" + html.EscapeString(string(att.Content)) + "</code></pre>\n")
+}
+
+// codeBlock escapes code and highlights Python comments, strings and
+// keywords.
+func codeBlock(code string) template.HTML {
+	var b strings.Builder
+	b.WriteString("<pre><code>")
+	last := 0
+	for _, m := range pythonToken.FindAllStringSubmatchIndex(code, -1) {
+		b.WriteString(html.EscapeString(code[last:m[0]]))
+		class := "kw"
+		switch {
+		case m[2] >= 0:
+			class = "com"
+		case m[4] >= 0:
+			class = "str"
+		}
+		b.WriteString(`<span class="` + class + `">` + html.EscapeString(code[m[0]:m[1]]) + "</span>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(code[last:]))
+	b.WriteString("</code></pre>\n")
+	return template.HTML(b.String())
+}
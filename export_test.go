@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportByDepth(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.record(
+		NewMessage(nil, "review", "SimpleReviewer", CauseWriteReview),
+		NewMessage(nil, "tests", "SimpleTester", CauseWriteTest),
+		NewMessage(nil, "idea", "Human", CauseUserRequirement),
+		NewMessage(nil, "code", "SimpleCoder", CauseWriteCode),
+	)
+	want := []CauseBy{CauseUserRequirement, CauseWriteCode, CauseWriteTest, CauseWriteReview}
+
+	var buf bytes.Buffer
+	if err := team.ExportJSON(&buf, ExportOptions{ByDepth: true}); err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ Messages []Message }
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := causes(out.Messages); !slices.Equal(got, want) {
+		t.Errorf("JSON order %v, want %v", got, want)
+	}
+
+	buf.Reset()
+	if err := team.ExportHTML(&buf, ExportOptions{ByDepth: true}); err != nil {
+		t.Fatal(err)
+	}
+	page, last := buf.String(), -1
+	for _, c := range want {
+		i := strings.Index(page, `<div class="meta">`+string(c))
+		if i < last {
+			t.Errorf("HTML shows %s out of depth order", c)
+		}
+		last = i
+	}
+
+	buf.Reset()
+	if err := team.ExportJSON(&buf, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Messages[0].CauseBy != CauseWriteReview {
+		t.Errorf("without ByDepth the export reordered the transcript: %v", causes(out.Messages))
+	}
+}
+
+var (
+	htmlTag      = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)\b[^<>]*>`)
+	voidElements = map[string]bool{"meta": true, "br": true, "hr": true, "img": true, "link": true, "input": true}
+)
+
+// checkHTML fails t unless page is a complete HTML document whose elements
+// all nest properly and whose text holds no stray markup.
+func checkHTML(t *testing.T, page string) {
+	t.Helper()
+	rest, ok := strings.CutPrefix(page, "<!DOCTYPE html>")
+	if !ok {
+		t.Fatal("page does not start with a doctype")
+	}
+	var open []string
+	last := 0
+	for _, m := range htmlTag.FindAllStringSubmatchIndex(rest, -1) {
+		if text := rest[last:m[0]]; strings.ContainsAny(text, "<>") {
+			t.Fatalf("unescaped markup in %q", text)
+		}
+		last = m[1]
+		closing, name := rest[m[2]:m[3]] == "/", strings.ToLower(rest[m[4]:m[5]])
+		switch {
+		case voidElements[name]:
+		case !closing:
+			open = append(open, name)
+		case len(open) == 0 || open[len(open)-1] != name:
+			t.Fatalf("</%s> closes %v", name, open)
+		default:
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) != 0 {
+		t.Errorf("unclosed elements %v", open)
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := team.ExportHTML(&buf, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	checkHTML(t, page)
+	text := htmlTag.ReplaceAllString(page, "")
+	for _, msg := range team.Transcript() {
+		for _, line := range strings.Split(msg.Content, "\n") {
+			if !strings.Contains(text, html.EscapeString(strings.TrimSpace(line))) {
+				t.Errorf("page lacks %s line %q", msg.CauseBy, line)
+			}
+		}
+	}
+}
+
+func TestExportHTMLAttachments(t *testing.T) {
+	msg := NewMessage(nil, "code", "SimpleCoder", CauseWriteCode)
+	msg.Attachments = []Attachment{{Name: "requirements.txt", Content: []byte("numpy<2 & <script>")}}
+
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, "idea", []Message{msg}, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{"<h3>requirements.txt</h3>", "<pre><code>numpy&lt;2 &amp; &lt;script&gt;</code></pre>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestExportTruncation(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	for i := 0; i < 10; i++ {
+		team.record(NewMessage(nil, strings.Repeat(string(rune('a'+i)), 10), "SimpleCoder", CauseWriteCode))
+	}
+	for _, tc := range []struct {
+		name   string
+		opts   ExportOptions
+		kept   int
+		notice string
+	}{
+		{"no caps", ExportOptions{}, 10, ""},
+		{"messages", ExportOptions{MaxMessages: 3}, 3, "7 earlier messages of 10 omitted"},
+		{"bytes", ExportOptions{MaxBytes: 45}, 4, "6 earlier messages of 10 omitted"},
+		{"both", ExportOptions{MaxMessages: 3, MaxBytes: 25}, 2, "8 earlier messages of 10 omitted"},
+		{"caps above size", ExportOptions{MaxMessages: 20, MaxBytes: 1000}, 10, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := team.ExportJSON(&buf, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			var out struct {
+				Notice   string
+				Messages []Message
+			}
+			if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Messages) != tc.kept || out.Notice != tc.notice {
+				t.Fatalf("kept %d with notice %q, want %d and %q", len(out.Messages), out.Notice, tc.kept, tc.notice)
+			}
+			if newest := strings.Repeat("j", 10); out.Messages[len(out.Messages)-1].Content != newest {
+				t.Errorf("the newest message was dropped")
+			}
+
+			buf.Reset()
+			if err := team.ExportHTML(&buf, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			page := buf.String()
+			checkHTML(t, page)
+			if got := strings.Count(page, `<div class="meta">`); got != tc.kept {
+				t.Errorf("HTML shows %d messages, want %d", got, tc.kept)
+			}
+			if tc.notice != "" && !strings.Contains(page, tc.notice) {
+				t.Errorf("HTML lacks the notice %q", tc.notice)
+			}
+			if tc.notice == "" && strings.Contains(page, "omitted") {
+				t.Errorf("HTML has a notice although nothing was left out")
+			}
+		})
+	}
+}
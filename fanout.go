@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ActFanOut runs the role's action once per input, with at most maxWorkers
+// (all of them if maxWorkers <= 0) running at a time, and returns the
+// messages in the order of inputs. Each input is the whole context of its
+// run. On the first error the remaining runs are cancelled and nothing is
+// added to memory; otherwise all messages are, in order.
+func (r *Role) ActFanOut(ctx context.Context, inputs []string, maxWorkers int) ([]Message, error) {
+	actions := r.selectedActions()
+	if len(actions) == 0 {
+		return nil, errors.New("no suitable action found")
+	}
+	action := actions[0]
+	if maxWorkers <= 0 || maxWorkers > len(inputs) {
+		maxWorkers = len(inputs)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var parentID string
+	if recent := r.Memory.GetRecentN(1); len(recent) > 0 {
+		parentID = recent[0].ID
+	}
+
+	msgs := make([]Message, len(inputs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxWorkers)
+dispatch:
+	for i, input := range inputs {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func(i int, input string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			actCtx, meta := withMeta(ctx)
+			output, err := action.Run(actCtx, input)
+			if err != nil {
+				cancel(fmt.Errorf("%s action failed on input %d: %w", action.Name(), i, err))
+				return
+			}
+			msg := newMessage(r.Clock, r.IDGenerator, output, r.Profile, action.Name())
+			msg.Meta = meta.snapshot()
+			msg.ParentID = parentID
+			msgs[i] = msg
+		}(i, input)
+	}
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return nil, err
+	}
+	for _, msg := range msgs {
+		r.Memory.Add(msg)
+	}
+	return msgs, nil
+}
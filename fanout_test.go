@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fanAction treats its input as a number n, sleeps for 10-n milliseconds so
+// that later inputs finish first, and fails on fail.
+type fanAction struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	fail    int
+}
+
+func (a *fanAction) Name() CauseBy { return "Fan" }
+
+func (a *fanAction) Run(ctx context.Context, input string) (string, error) {
+	n, err := strconv.Atoi(input)
+	if err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	a.running++
+	a.peak = max(a.peak, a.running)
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.running--
+		a.mu.Unlock()
+	}()
+
+	if n == a.fail {
+		return "", errors.New("boom")
+	}
+	select {
+	case <-time.After(time.Duration(10-n) * time.Millisecond):
+		return "done " + input, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func fanInputs(n int) []string {
+	inputs := make([]string, n)
+	for i := range inputs {
+		inputs[i] = strconv.Itoa(i)
+	}
+	return inputs
+}
+
+func TestActFanOutKeepsOrder(t *testing.T) {
+	a := &fanAction{fail: -1}
+	r := &Role{Name: "Fan", Profile: "Fanner", Actions: []Action{a}, Memory: &Memory{}}
+
+	msgs, err := r.ActFanOut(context.Background(), fanInputs(10), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 10 {
+		t.Fatalf("got %d messages, want 10", len(msgs))
+	}
+	for i, msg := range msgs {
+		if want := fmt.Sprintf("done %d", i); msg.Content != want {
+			t.Errorf("message %d is %q, want %q", i, msg.Content, want)
+		}
+	}
+	if a.peak > 3 {
+		t.Errorf("%d runs at once, limit 3", a.peak)
+	}
+	if a.peak < 2 {
+		t.Errorf("runs did not overlap (peak %d)", a.peak)
+	}
+	stored := r.Memory.GetRecentN(-1)
+	for i := range msgs {
+		if stored[i].ID != msgs[i].ID {
+			t.Fatalf("memory holds the messages out of order")
+		}
+	}
+}
+
+func TestActFanOutStopsOnError(t *testing.T) {
+	a := &fanAction{fail: 2}
+	r := &Role{Name: "Fan", Profile: "Fanner", Actions: []Action{a}, Memory: &Memory{}}
+
+	msgs, err := r.ActFanOut(context.Background(), fanInputs(10), 2)
+	if err == nil || msgs != nil {
+		t.Fatalf("got %d messages and %v, want the failure", len(msgs), err)
+	}
+	if n := len(r.Memory.GetRecentN(-1)); n != 0 {
+		t.Errorf("failed fan-out left %d messages in memory", n)
+	}
+}
+
+func TestActFanOutHonoursContext(t *testing.T) {
+	r := &Role{Name: "Fan", Profile: "Fanner", Actions: []Action{&fanAction{fail: -1}}, Memory: &Memory{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	inputs := make([]string, 50)
+	for i := range inputs {
+		inputs[i] = "0"
+	}
+	start := time.Now()
+	if _, err := r.ActFanOut(ctx, inputs, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("cancelled fan-out ran for %v", elapsed)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// expectedLanguage is the language parseCode prefers when a reply has
+// fences in several languages.
+const expectedLanguage = "python"
+
+var (
+	fenceMu sync.RWMutex
+	// fencePatterns are tried in order by parseCode; the first submatch of
+	// the first pattern that matches is the extracted code. The fence for
+	// expectedLanguage, under any of its aliases, is tried after the
+	// four-backtick one. The last genericFences patterns match any fence
+	// and stay last.
+	fencePatterns = []*regexp.Regexp{
+		regexp.MustCompile("(?s)````[\\w+-]*[ \\t]*\\n(.*?)````"),
+		nil,
+		regexp.MustCompile("(?s)```[\\w+-]*[ \\t]*\\n(.*?)```"),
+		regexp.MustCompile("(?s)~~~[\\w+-]*[ \\t]*\\n(.*?)~~~"),
+	}
+	// languageAliases maps fence tags to their canonical language.
+	languageAliases = map[string]string{
+		"py":         "python",
+		"py3":        "python",
+		"python3":    "python",
+		"js":         "javascript",
+		"ts":         "typescript",
+		"golang":     "go",
+		"sh":         "bash",
+		"shell":      "bash",
+		"yml":        "yaml",
+		"postgresql": "sql",
+	}
+)
+
+// genericFences is the number of patterns at the end of fencePatterns
+// that match a fence with any tag.
+const genericFences = 2
+
+func init() {
+	fencePatterns[1] = languageFence(expectedLanguage)
+}
+
+// languageFence matches a ``` fence tagged lang or one of its aliases.
+// Callers hold fenceMu or run before any concurrent use.
+func languageFence(lang string) *regexp.Regexp {
+	tags := []string{regexp.QuoteMeta(lang)}
+	for alias, canonical := range languageAliases {
+		if canonical == lang {
+			tags = append(tags, regexp.QuoteMeta(alias))
+		}
+	}
+	sort.Strings(tags)
+	return regexp.MustCompile("(?is)```(?:" + strings.Join(tags, "|") + ")[ \\t]*\\n(.*?)```")
+}
+
+// RegisterLanguageAlias makes fences tagged alias count as canonical, both
+// for the language ParseCodeLang reports and for parseCode's preference
+// for Python fences.
+func RegisterLanguageAlias(alias, canonical string) {
+	fenceMu.Lock()
+	defer fenceMu.Unlock()
+	languageAliases[strings.ToLower(alias)] = strings.ToLower(canonical)
+	fencePatterns[1] = languageFence(expectedLanguage)
+}
+
+// canonicalLanguage resolves a lower-cased fence tag through the aliases.
+func canonicalLanguage(tag string) string {
+	fenceMu.RLock()
+	defer fenceMu.RUnlock()
+	if canonical, ok := languageAliases[tag]; ok {
+		return canonical
+	}
+	return tag
+}
+
+// RegisterFencePattern adds a code fence style for parseCode to recognise.
+// The pattern's first capture group must hold the code. Registered patterns
+// are tried in the order they were registered, before the fences that accept
+// any tag, so they can claim fences those would also match.
+func RegisterFencePattern(re *regexp.Regexp) {
+	fenceMu.Lock()
+	defer fenceMu.Unlock()
+	fencePatterns = slices.Insert(fencePatterns, len(fencePatterns)-genericFences, re)
+}
+
+func currentFencePatterns() []*regexp.Regexp {
+	fenceMu.RLock()
+	defer fenceMu.RUnlock()
+	return append([]*regexp.Regexp(nil), fencePatterns...)
+}
+
+// ParseCodeLang is parseCode that also returns the language of the fence
+// the code was found in: its tag, lower-cased and with aliases resolved,
+// e.g. "python" for ```python or ```py.
+// The tag is empty for untagged fences and when no fence matched, in which
+// case code is rsp itself.
+func ParseCodeLang(rsp string) (code, lang string) {
+	code, lang, ok := findCode(rsp)
+	if !ok {
+		return rsp, ""
+	}
+	return code, lang
+}
+
+// findCode is ParseCodeLang reporting whether any fence matched.
+func findCode(rsp string) (code, lang string, ok bool) {
+	for _, re := range currentFencePatterns() {
+		loc := re.FindStringSubmatchIndex(rsp)
+		if len(loc) < 4 {
+			continue
+		}
+		if loc[2] < 0 {
+			return "", "", true
+		}
+		return strings.TrimSpace(rsp[loc[2]:loc[3]]), fenceLang(rsp[loc[0]:loc[2]]), true
+	}
+	return "", "", false
+}
+
+var fenceOpener = regexp.MustCompile("^(?:`{3,}|~{3,})([\\w+-]*)")
+
+// fenceLang reads the language tag from the opening of a fence.
+func fenceLang(opening string) string {
+	m := fenceOpener.FindStringSubmatch(opening)
+	if m == nil {
+		return ""
+	}
+	return canonicalLanguage(strings.ToLower(m[1]))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseCodeFenceStyles(t *testing.T) {
+	for _, tc := range []struct {
+		name, reply string
+	}{
+		{"tilde", "Here it is:\n~~~python\n" + sampleCode + "\n~~~\nDone."},
+		{"four backticks", "````python\n" + sampleCode + "\n````"},
+		{"four backticks around a nested fence", "````\n" + sampleCode + "\n````\n```python\nprint(1)\n```"},
+	} {
+		if got := parseCode(tc.reply); got != sampleCode {
+			t.Errorf("%s: got %q", tc.name, got)
+		}
+	}
+}
+
+func TestRegisterFencePattern(t *testing.T) {
+	old := currentFencePatterns()
+	defer func() {
+		fenceMu.Lock()
+		fencePatterns = old
+		fenceMu.Unlock()
+	}()
+
+	reply := "<code>\n" + sampleCode + "\n</code>"
+	if got := parseCode(reply); got != reply {
+		t.Fatalf("unregistered style parsed as %q", got)
+	}
+	RegisterFencePattern(regexp.MustCompile(`(?s)<code>\n(.*?)</code>`))
+	if got := parseCode(reply); got != sampleCode {
+		t.Errorf("registered style: got %q", got)
+	}
+}
+
+func TestRegisterFencePatternOverlappingBuiltIn(t *testing.T) {
+	old := currentFencePatterns()
+	defer func() {
+		fenceMu.Lock()
+		fencePatterns = old
+		fenceMu.Unlock()
+	}()
+
+	// The generic ``` fence would take the first block.
+	reply := "```text\nthe function multiplies\n```\n```solution\n" + sampleCode + "\n```"
+	RegisterFencePattern(regexp.MustCompile("(?s)```solution\\n(.*?)```"))
+	RegisterFencePattern(regexp.MustCompile("(?s)```text\\n(.*?)```"))
+	code, lang := ParseCodeLang(reply)
+	if code != sampleCode || lang != "solution" {
+		t.Errorf("got (%q, %q), want the solution fence", code, lang)
+	}
+	// Python fences are still preferred.
+	if got := parseCode(reply + "\n```python\nprint(1)\n```"); got != "print(1)" {
+		t.Errorf("registered pattern beat the python fence: %q", got)
+	}
+}
+
+func TestParseCodeLang(t *testing.T) {
+	for _, tc := range []struct {
+		name, reply, code, lang string
+	}{
+		{"python", "```python\n" + sampleCode + "\n```", sampleCode, "python"},
+		{"go", "Sure:\n```go\nfunc main() {}\n```\n", "func main() {}", "go"},
+		{"upper-case tag", "```TypeScript\nlet x = 1;\n```", "let x = 1;", "typescript"},
+		{"c++", "```c++\nint main() {}\n```", "int main() {}", "c++"},
+		{"tilde fence", "~~~rust\nfn main() {}\n~~~", "fn main() {}", "rust"},
+		{"no tag", "```\nSELECT 1;\n```", "SELECT 1;", ""},
+		{"python preferred", "```go\nfunc main() {}\n```\n```python\nprint(1)\n```", "print(1)", "python"},
+		{"no fence", "just prose", "just prose", ""},
+	} {
+		code, lang := ParseCodeLang(tc.reply)
+		if code != tc.code || lang != tc.lang {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tc.name, code, lang, tc.code, tc.lang)
+		}
+	}
+}
+
+func TestParseCodeLanguageAliases(t *testing.T) {
+	for _, tag := range []string{"py", "python3", "Python", "PY3"} {
+		reply := "```js\nconsole.log(1);\n```\n```" + tag + "\n" + sampleCode + "\n```"
+		if got := parseCode(reply); got != sampleCode {
+			t.Errorf("```%s not preferred as python: got %q", tag, got)
+		}
+		if _, lang := ParseCodeLang("```" + tag + "\nx = 1\n```"); lang != "python" {
+			t.Errorf("```%s detected as %q", tag, lang)
+		}
+	}
+	if _, lang := ParseCodeLang("```js\nlet x;\n```"); lang != "javascript" {
+		t.Errorf("```js detected as %q", lang)
+	}
+}
+
+func TestRegisterLanguageAlias(t *testing.T) {
+	fenceMu.RLock()
+	oldAliases := make(map[string]string, len(languageAliases))
+	for k, v := range languageAliases {
+		oldAliases[k] = v
+	}
+	fenceMu.RUnlock()
+	defer func() {
+		fenceMu.Lock()
+		languageAliases = oldAliases
+		fencePatterns[1] = languageFence(expectedLanguage)
+		fenceMu.Unlock()
+	}()
+
+	reply := "```sh\necho hi\n```\n```cpython\n" + sampleCode + "\n```"
+	if got := parseCode(reply); got == sampleCode {
+		t.Fatal("unregistered alias preferred as python")
+	}
+	RegisterLanguageAlias("CPython", "Python")
+	if got := parseCode(reply); got != sampleCode {
+		t.Errorf("registered alias not preferred: got %q", got)
+	}
+	if _, lang := ParseCodeLang("```cpython\nx = 1\n```"); lang != "python" {
+		t.Errorf("registered alias detected as %q", lang)
+	}
+}
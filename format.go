@@ -0,0 +1,30 @@
+package main
+
+import "context"
+
+// formatContext formats msgs with the role's ContextFormatter.
+func (r *Role) formatContext(msgs []Message) string {
+	if r.ContextFormatter != nil {
+		return r.ContextFormatter(msgs)
+	}
+	return formatContext(msgs)
+}
+
+type contextFormatterKey struct{}
+
+// withContextFormatter hands the acting role's ContextFormatter to message
+// actions, which format their messages themselves.
+func withContextFormatter(ctx context.Context, f func([]Message) string) context.Context {
+	if f == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextFormatterKey{}, f)
+}
+
+// formatContextFor formats msgs as the role acting under ctx would.
+func formatContextFor(ctx context.Context, msgs []Message) string {
+	if f, ok := ctx.Value(contextFormatterKey{}).(func([]Message) string); ok {
+		return f(msgs)
+	}
+	return formatContext(msgs)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// tagFormatter wraps every message in <msg role=...> tags.
+func tagFormatter(msgs []Message) string {
+	var b strings.Builder
+	for _, msg := range msgs {
+		fmt.Fprintf(&b, "<msg role=%q>\n%s\n</msg>\n", msg.Role, msg.Content)
+	}
+	return b.String()
+}
+
+func TestContextFormatter(t *testing.T) {
+	for _, custom := range []bool{true, false} {
+		p := newPipelineProvider()
+		team := newPipelineTeam(t, p)
+		if custom {
+			for _, r := range team.Roles {
+				r.ContextFormatter = tagFormatter
+			}
+		}
+		if _, err := team.RunProject(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		prompts := p.prompts()
+		for i, tc := range []struct{ role, content string }{
+			{"User", "write a function that returns the product of a list"},
+			{"SimpleCoder", sampleCode},
+			{"SimpleTester", sampleTests},
+		} {
+			tagged := fmt.Sprintf("<msg role=%q>\n%s\n</msg>", tc.role, tc.content)
+			plain := fmt.Sprintf("[%s]: %s", tc.role, tc.content)
+			if got := strings.Contains(prompts[i], tagged); got != custom {
+				t.Errorf("custom %v: prompt %d tagged %v:\n%s", custom, i, got, prompts[i])
+			}
+			if got := strings.Contains(prompts[i], plain); got == custom {
+				t.Errorf("custom %v: prompt %d in the default format %v:\n%s", custom, i, got, prompts[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// GenerateCode runs the preset coder, tester and reviewer once on idea,
+// printing nothing, and returns the code the coder wrote. It is a shortcut
+// for callers that do not need to assemble a team themselves.
+func GenerateCode(ctx context.Context, llm LLMProvider, idea string) (string, error) {
+	var roles []*Role
+	for _, name := range []string{"Coder", "Tester", "Reviewer"} {
+		role, err := PresetRole(name, llm)
+		if err != nil {
+			return "", err
+		}
+		roles = append(roles, role)
+	}
+
+	team := &Team{Roles: roles, ProjectIdea: idea, Output: io.Discard}
+	msgs, err := team.RunProject(ctx)
+	if err != nil {
+		return "", err
+	}
+	code := latestContent(msgs, CauseWriteCode)
+	if code == "" {
+		return "", errors.New("generate code: the coder produced no code")
+	}
+	return code, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestGenerateCode(t *testing.T) {
+	p := newPipelineProvider()
+	code, err := GenerateCode(context.Background(), p, "return the product of a list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != sampleCode {
+		t.Errorf("got %q, want the coder's code", code)
+	}
+
+	prompts := p.prompts()
+	if len(prompts) != 3 {
+		t.Fatalf("%d calls, want coder, tester and reviewer", len(prompts))
+	}
+	for i, want := range []string{"return the product of a list", sampleCode, sampleTests} {
+		if !strings.Contains(prompts[i], want) {
+			t.Errorf("prompt %d lacks %q:\n%s", i, want, prompts[i])
+		}
+	}
+}
+
+func TestGenerateCodeFails(t *testing.T) {
+	down := errors.New("provider down")
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "", down }}
+	code, err := GenerateCode(context.Background(), p, "return the product of a list")
+	if err == nil || code != "" {
+		t.Errorf("got %q, %v, want an error", code, err)
+	}
+}
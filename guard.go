@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrBlockedInput is returned (wrapped) when a guard rejects the project
+// idea or other user input.
+var ErrBlockedInput = errors.New("input blocked by guard")
+
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bignore\s+(all\s+)?(the\s+)?(previous|prior|above)\s+(instructions|prompts?)\b`),
+	regexp.MustCompile(`(?i)\bdisregard\s+(all\s+)?(your|the)\s+(instructions|rules|guidelines)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|show)\s+(your|the)\s+system\s+prompt\b`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+no\s+longer\s+bound\s+by\b`),
+	regexp.MustCompile(`(?i)\b(write|create)\s+(a\s+)?(ransomware|keylogger|malware)\b`),
+}
+
+// DefaultGuard rejects input matching a small set of well-known prompt
+// injection and disallowed-content phrasings. It is used when Team.Guard is
+// nil.
+func DefaultGuard(input string) error {
+	for _, re := range injectionPatterns {
+		if m := re.FindString(input); m != "" {
+			return fmt.Errorf("%w: %q", ErrBlockedInput, m)
+		}
+	}
+	return nil
+}
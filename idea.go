@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const defaultProjectIdea = "write a function that calculates the product of a list"
+
+// readProjectIdea picks the project idea from, in order of precedence, the
+// -idea flag and the file named by -idea-file, falling back to the built-in
+// example. An -idea-file of "-" reads stdin, blocking until it is closed;
+// stdin is never read otherwise, so a run whose stdin is an open pipe does
+// not hang. An empty stdin also falls back to the example.
+func readProjectIdea(idea, file string, stdin io.Reader) (string, error) {
+	if idea != "" {
+		return idea, nil
+	}
+	if file == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading idea from stdin: %w", err)
+		}
+		if piped := strings.TrimSpace(string(data)); piped != "" {
+			return piped, nil
+		}
+		return defaultProjectIdea, nil
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("reading idea file: %w", err)
+		}
+		return nonEmptyIdea(string(data), file)
+	}
+	return defaultProjectIdea, nil
+}
+
+func nonEmptyIdea(idea, source string) (string, error) {
+	idea = strings.TrimSpace(idea)
+	if idea == "" {
+		return "", fmt.Errorf("project idea from %s is empty", source)
+	}
+	return idea, nil
+}
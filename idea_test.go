@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pipeStdin returns a pipe's read end with content written to it.
+func pipeStdin(t *testing.T, content string) *os.File {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	go func() {
+		w.WriteString(content)
+		w.Close()
+	}()
+	return r
+}
+
+func TestReadProjectIdea(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "idea.txt")
+	if err := os.WriteFile(file, []byte("  idea from file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name, flag, file string
+		stdin            string
+		useStdin         bool
+		want             string
+	}{
+		{"flag beats file and stdin", "idea from flag", "-", "idea from stdin", true, "idea from flag"},
+		{"file", "", file, "idea from stdin", true, "idea from file"},
+		{"stdin", "", "-", "idea from stdin\n", true, "idea from stdin"},
+		{"empty stdin", "", "-", "", true, defaultProjectIdea},
+		{"blank stdin", "", "-", " \n\t", true, defaultProjectIdea},
+		{"stdin only with -", "", "", "idea from stdin", true, defaultProjectIdea},
+		{"default", "", "", "", false, defaultProjectIdea},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdin *os.File
+			if tc.useStdin {
+				stdin = pipeStdin(t, tc.stdin)
+			}
+			got, err := readProjectIdea(tc.flag, tc.file, stdin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadProjectIdeaErrors(t *testing.T) {
+	if _, err := readProjectIdea("", filepath.Join(t.TempDir(), "missing.txt"), nil); err == nil {
+		t.Error("missing file accepted")
+	}
+	blank := filepath.Join(t.TempDir(), "blank.txt")
+	if err := os.WriteFile(blank, []byte(" \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readProjectIdea("", blank, nil); err == nil {
+		t.Error("blank idea file accepted")
+	}
+}
+
+func TestReadProjectIdeaIgnoresOpenStdin(t *testing.T) {
+	// A pipe nobody writes to or closes, as a scheduler may leave stdin.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		idea, _ := readProjectIdea("", "", r)
+		done <- idea
+	}()
+	select {
+	case idea := <-done:
+		if idea != defaultProjectIdea {
+			t.Errorf("got %q, want the default idea", idea)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readProjectIdea blocked on an open stdin")
+	}
+}
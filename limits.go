@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// InputLimiter is implemented by actions that cap the size of the context
+// Role.Act hands them.
+type InputLimiter interface {
+	InputLimit() int
+}
+
+// OutputTooLargeError is returned when a model reply exceeds the action's
+// MaxOutputBytes, which usually means a runaway generation.
+type OutputTooLargeError struct {
+	Size, Limit int
+}
+
+func (e *OutputTooLargeError) Error() string {
+	return fmt.Sprintf("output of %d bytes exceeds the %d byte limit", e.Size, e.Limit)
+}
+
+func (o ActionOptions) InputLimit() int { return o.MaxInputBytes }
+
+// truncateInput keeps the newest max bytes of s, dropping older context and
+// marking the cut. A non-positive max leaves s alone.
+func truncateInput(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := len(s) - max
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return fmt.Sprintf("[... %d bytes of earlier context truncated ...]\n%s", cut, s[cut:])
+}
+
+func inputLimit(a Action) int {
+	if l, ok := a.(InputLimiter); ok {
+		return l.InputLimit()
+	}
+	return 0
+}
+
+// TeamTooLargeError is returned when a run's team exceeds Team.MaxRoles or
+// Team.MaxActions. What is "roles" or "actions".
+type TeamTooLargeError struct {
+	What         string
+	Count, Limit int
+}
+
+func (e *TeamTooLargeError) Error() string {
+	return fmt.Sprintf("team has %d %s, more than the limit of %d", e.Count, e.What, e.Limit)
+}
+
+// checkSize enforces MaxRoles and MaxActions on the team's current roles.
+func (t *Team) checkSize() error {
+	roles := t.roles()
+	if t.MaxRoles > 0 && len(roles) > t.MaxRoles {
+		return &TeamTooLargeError{What: "roles", Count: len(roles), Limit: t.MaxRoles}
+	}
+	if t.MaxActions > 0 {
+		actions := 0
+		for _, r := range roles {
+			actions += len(r.Actions)
+		}
+		if actions > t.MaxActions {
+			return &TeamTooLargeError{What: "actions", Count: actions, Limit: t.MaxActions}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestTruncateInput(t *testing.T) {
+	if got := truncateInput("short", 100); got != "short" {
+		t.Errorf("short input changed to %q", got)
+	}
+	got := truncateInput(strings.Repeat("old ", 50)+"newest", 20)
+	if !strings.HasPrefix(got, "[... 186 bytes of earlier context truncated ...]\n") || !strings.HasSuffix(got, "newest") {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateInput("héllo", 4); !strings.HasSuffix(got, "llo") {
+		t.Errorf("cut inside a rune: %q", got)
+	}
+}
+
+func TestActionSizeLimits(t *testing.T) {
+	p := newPipelineProvider()
+	r := &Role{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteReview{llmClient: p, ActionOptions: ActionOptions{ContextMessages: -1, MaxInputBytes: 200}}}}
+	r.Memory.Add(NewMessage(nil, strings.Repeat("ancient history ", 100), "Human", CauseUserRequirement))
+	r.Memory.Add(NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest))
+	if _, err := r.Act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	prompt := p.prompts()[0]
+	if !strings.Contains(prompt, "bytes of earlier context truncated") || !strings.Contains(prompt, sampleTests) {
+		t.Errorf("prompt not truncated to the newest context:\n%s", prompt)
+	}
+
+	big := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return strings.Repeat("x", 1000), nil }}
+	_, err := ActionOptions{MaxOutputBytes: 100}.chat(context.Background(), big, CauseWriteReview, "review")
+	var tooLarge *OutputTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 1000 || tooLarge.Limit != 100 {
+		t.Errorf("got %v, want an OutputTooLargeError", err)
+	}
+}
+
+func TestTeamSizeLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		maxRoles, maxAction int
+		what                string
+	}{
+		{"unlimited", 0, 0, ""},
+		{"within limits", 3, 3, ""},
+		{"too many roles", 2, 0, "roles"},
+		{"too many actions", 0, 2, "actions"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPipelineProvider()
+			team := newPipelineTeam(t, p)
+			team.MaxRoles, team.MaxActions = tc.maxRoles, tc.maxAction
+
+			msgs, err := team.RunProject(context.Background())
+			if tc.what == "" {
+				if err != nil || len(msgs) != 3 {
+					t.Fatalf("got %d messages and %v, want a full run", len(msgs), err)
+				}
+				return
+			}
+			var tooLarge *TeamTooLargeError
+			if !errors.As(err, &tooLarge) || tooLarge.What != tc.what || tooLarge.Count != 3 {
+				t.Fatalf("got %v, want a TeamTooLargeError on %s", err, tc.what)
+			}
+			if len(msgs) != 0 || p.calls() != 0 {
+				t.Errorf("oversized team still ran: %d messages, %d calls", len(msgs), p.calls())
+			}
+		})
+	}
+}
+
+func TestTeamSizeLimitsCountAddedRoles(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.MaxRoles = 3
+	team.Supervisor = func(ctx context.Context, team *Team, produced []Message) {
+		if team.Round() == 1 {
+			team.AddRole(&Role{Name: "Extra", Profile: "Extra", Actions: []Action{&captureAction{}}})
+		}
+	}
+	msgs, err := team.RunProjectRounds(context.Background(), 3)
+	var tooLarge *TeamTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Count != 4 {
+		t.Errorf("got %v, want the grown team rejected", err)
+	}
+	if len(msgs) != 3 {
+		t.Errorf("got %d messages, want the first round's 3", len(msgs))
+	}
+}
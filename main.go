@@ -2,186 +2,697 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
 )
 
 type Message struct {
-	Content string
-	Role    string
-	CauseBy string
+	ID string
+	// ParentID is the ID of the message the producing role reacted to.
+	ParentID  string
+	Content   string
+	Role      string
+	CauseBy   CauseBy
+	Timestamp time.Time
+	// Priority orders delivery of messages produced in the same wave;
+	// higher values are delivered last, i.e. closest to the watcher's
+	// attention.
+	Priority int
+	// Images holds image URLs (data: URLs included) for vision-capable
+	// models; see ImageDataURL.
+	Images []string
+	// Meta holds extra facts recorded by the producing action, such as
+	// structured review comments.
+	Meta map[string]string
+	// Attachments carries whole files alongside Content, e.g. the code
+	// together with a requirements.txt.
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name    string
+	Content []byte
 }
 
 type Memory struct {
 	mu      sync.Mutex
 	history []Message
+	// MaxMessages, if positive, bounds the history; the oldest messages
+	// are dropped first.
+	MaxMessages int
+	// DedupThreshold, if positive, makes Add drop a message whose content
+	// is at least that similar to one of the last few messages with the
+	// same CauseBy. Similarity measures it; nil uses TokenSimilarity.
+	DedupThreshold float64
+	Similarity     func(a, b string) float64
+	// TTL, if positive, hides messages older than that from every read;
+	// Prune removes them. Messages without a timestamp never expire. Clock
+	// tells the time; nil uses the wall clock.
+	TTL   time.Duration
+	Clock Clock
 }
 
+// dedupWindow is how many earlier messages of the same cause Add compares
+// a new one against.
+const dedupWindow = 5
+
 func (m *Memory) Add(msg Message) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.DedupThreshold > 0 && m.isNearDuplicate(msg) {
+		return
+	}
 	//使用切片存储历史消息
 	m.history = append(m.history, msg)
+	if m.MaxMessages > 0 && len(m.history) > m.MaxMessages {
+		m.history = m.history[len(m.history)-m.MaxMessages:]
+	}
+}
+
+func (m *Memory) isNearDuplicate(msg Message) bool {
+	sim := m.Similarity
+	if sim == nil {
+		sim = TokenSimilarity
+	}
+	history := m.live()
+	seen := 0
+	for i := len(history) - 1; i >= 0 && seen < dedupWindow; i-- {
+		if history[i].CauseBy != msg.CauseBy {
+			continue
+		}
+		seen++
+		if sim(history[i].Content, msg.Content) >= m.DedupThreshold {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *Memory) GetRecent() []Message {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if len(m.history) == 0 {
+	history := m.live()
+	if len(history) == 0 {
 		return nil
 	}
-	return m.history[len(m.history)-1:]
+	return history[len(history)-1:]
+}
+
+// GetRecentN returns a copy of the last n messages. A negative n returns the
+// whole history.
+func (m *Memory) GetRecentN(n int) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := m.live()
+	if n < 0 || n > len(history) {
+		n = len(history)
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make([]Message, n)
+	copy(out, history[len(history)-n:])
+	return out
+}
+
+// GetByCauseBy returns copies of the newest n messages caused by causeBy,
+// oldest first; a negative n returns all of them.
+func (m *Memory) GetByCauseBy(causeBy CauseBy, n int) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return latestByCause(m.live(), causeBy, n)
+}
+
+// Search returns copies of the messages whose content matches the regular
+// expression pattern, oldest first.
+func (m *Memory) Search(pattern string) ([]Message, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("memory search: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Message
+	for _, msg := range m.live() {
+		if re.MatchString(msg.Content) {
+			out = append(out, msg)
+		}
+	}
+	return out, nil
+}
+
+// Compact replaces the oldest n messages with summary, keeping anything
+// added after them. Expired messages are pruned first, so n counts the
+// messages the reads return.
+func (m *Memory) Compact(n int, summary Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	if n > len(m.history) {
+		n = len(m.history)
+	}
+	rest := m.history[n:]
+	m.history = append([]Message{summary}, rest...)
+}
+
+// replace makes msgs the whole history, as it was when they were read.
+func (m *Memory) replace(msgs []Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = append([]Message(nil), msgs...)
 }
 
 
 type Action interface {
 	Run(ctx context.Context, input string) (string, error)
-	Name() string
+	Name() CauseBy
+}
+
+// MessageAction is implemented by actions that work on the memory messages
+// themselves rather than their formatted text. Role.Act calls RunMessages
+// instead of Run for them.
+type MessageAction interface {
+	Action
+	RunMessages(ctx context.Context, msgs []Message) (string, error)
 }
 
+// ErrNoResponse is returned when the model keeps answering with no content.
+var ErrNoResponse = errors.New("no response from model")
+
+// ContextWindower is implemented by actions that want to control how many
+// recent memory messages Role.Act hands them as context.
+type ContextWindower interface {
+	ContextWindow() int
+}
+
+// ActionOptions holds the settings shared by the LLM-backed actions.
+type ActionOptions struct {
+	// Model is the model (or Azure deployment) to call; empty means gpt-4.
+	Model string
+	// ContextMessages is the number of recent messages passed as context:
+	// 0 keeps the default of the latest message, a negative value passes
+	// the whole history.
+	ContextMessages int
+	// LabelOutput tags the prompt and the returned content with the
+	// action's name, e.g. "// from SimpleWriteCode", to make saved
+	// transcripts easier to follow.
+	LabelOutput bool
+	// FallbackModel is retried once when the primary model or deployment
+	// is reported as not found. Other API errors are returned as is.
+	FallbackModel string
+	// PostProcessors run in order on every reply before it is parsed.
+	PostProcessors []PostProcessor
+	// EmptyRetries is how many more times a successful but empty reply is
+	// retried before the action fails with ErrNoResponse.
+	EmptyRetries int
+	// ErrorRetries is how many more times a failed provider call is
+	// retried, e.g. after a transient network or rate-limit error. Backoff
+	// spaces the retries out; its zero value retries at once.
+	ErrorRetries int
+	Backoff      Backoff
+	// MinAttemptTime is how long a provider call is expected to take at
+	// least. A retry is not started when the context's deadline would pass
+	// before its backoff delay plus MinAttemptTime; the last error is
+	// returned instead, marked ErrDeadlineTooClose.
+	MinAttemptTime time.Duration
+	// MaxInputBytes truncates the context passed to the action to its
+	// newest bytes; MaxOutputBytes rejects longer replies with an
+	// *OutputTooLargeError. Zero means no limit.
+	MaxInputBytes  int
+	MaxOutputBytes int
+	// Vision marks the model as vision-capable, so images carried by
+	// context messages are sent along with the prompt. Without it they are
+	// ignored.
+	Vision bool
+	// PresencePenalty and FrequencyPenalty are forwarded to the request to
+	// discourage repetition; zero leaves the model defaults.
+	PresencePenalty  float32
+	FrequencyPenalty float32
+	// Temperature and MaxTokens are forwarded to the request, an explicit
+	// zero temperature included; unset (nil) falls back to LLM_TEMPERATURE
+	// and LLM_MAX_TOKENS from the environment, and without those to the
+	// model defaults.
+	Temperature *float32
+	MaxTokens   *int
+	// ContextPolicy, if set, replaces ContextMessages: the context holds
+	// the newest ContextPolicy[cause] messages of each listed cause, e.g.
+	// {"SimpleWriteCode": 1, "SimpleWriteReview": 3}, in memory order. A
+	// negative count keeps every message of that cause.
+	ContextPolicy map[CauseBy]int
+}
+
+func (o ActionOptions) ContextWindow() int { return o.ContextMessages }
+
+func (o ActionOptions) ModelName() string {
+	if o.Model == "" {
+		return "gpt-4"
+	}
+	return o.Model
+}
+
+// chat sends prompt to the model as a single user message and returns the
+// content of its reply.
+func (o ActionOptions) chat(ctx context.Context, client LLMProvider, name CauseBy, prompt string) (string, error) {
+	return o.chatWithImages(ctx, client, name, prompt, nil)
+}
+
+// chatWithImages is chat with image URLs attached to the message. Images are
+// dropped unless Vision is set.
+func (o ActionOptions) chatWithImages(ctx context.Context, client LLMProvider, name CauseBy, prompt string, images []string) (string, error) {
+	prompt = framePrompt(ctx, prompt)
+	if o.LabelOutput {
+		prompt = fmt.Sprintf("// action: %s\n%s", name, prompt)
+	}
+
+	userMsg := openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: prompt,
+	}
+	if o.Vision && len(images) > 0 {
+		userMsg.Content = ""
+		userMsg.MultiContent = []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: prompt}}
+		for _, url := range images {
+			userMsg.MultiContent = append(userMsg.MultiContent, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: url},
+			})
+		}
+	}
+
+	temperature, maxTokens, err := o.sampling()
+	if err != nil {
+		return "", err
+	}
+
+	// Azure OpenAI 调用配置
+	req := openai.ChatCompletionRequest{
+		Model:            o.ModelName(), // 使用部署名称而非模型ID
+		Messages:         []openai.ChatCompletionMessage{userMsg},
+		PresencePenalty:  o.PresencePenalty,
+		FrequencyPenalty: o.FrequencyPenalty,
+		Temperature:      requestTemperature(temperature),
+	}
+	if maxTokens != nil {
+		req.MaxTokens = *maxTokens
+	}
+
+	var content string
+	var delay time.Duration
+	for empties, errRetries := 0, 0; ; {
+		resp, err := createChatCompletion(ctx, client, req)
+		if err != nil && o.FallbackModel != "" && req.Model != o.FallbackModel && isModelNotFound(err) {
+			req.Model = o.FallbackModel
+			resp, err = createChatCompletion(ctx, client, req)
+		}
+		if err != nil {
+			if errRetries < o.ErrorRetries && ctx.Err() == nil {
+				next := o.Backoff.Delay(errRetries+1, delay)
+				if !timeLeft(ctx, next+o.MinAttemptTime) {
+					return "", fmt.Errorf("%s: %w (%w for a retry)", providerName(client), err, ErrDeadlineTooClose)
+				}
+				if allowRetry(ctx) {
+					errRetries++
+					delay = next
+					logf(ctx, "%s: %v; retrying in %v (%d/%d)", name, err, delay, errRetries, o.ErrorRetries)
+					if err := o.Backoff.wait(ctx, delay); err != nil {
+						return "", fmt.Errorf("%s: %w", providerName(client), err)
+					}
+					continue
+				}
+			}
+			return "", fmt.Errorf("%s: %w", providerName(client), err)
+		}
+
+		if choice, err := firstChoice(ctx, resp); err == nil && choice.Message.Content != "" {
+			content = choice.Message.Content
+			break
+		}
+		if empties >= o.EmptyRetries || !allowRetry(ctx) {
+			return "", fmt.Errorf("%s: %w", providerName(client), ErrNoResponse)
+		}
+		empties++
+	}
+
+	if o.MaxOutputBytes > 0 && len(content) > o.MaxOutputBytes {
+		return "", &OutputTooLargeError{Size: len(content), Limit: o.MaxOutputBytes}
+	}
+	return applyPostProcessors(content, o.PostProcessors), nil
+}
+
+// firstChoice returns the choice the actions use. It fails with
+// ErrNoResponse when there is none and logs when further choices, requested
+// with N > 1, are being discarded.
+func firstChoice(ctx context.Context, resp openai.ChatCompletionResponse) (openai.ChatCompletionChoice, error) {
+	if len(resp.Choices) == 0 {
+		return openai.ChatCompletionChoice{}, ErrNoResponse
+	}
+	if len(resp.Choices) > 1 {
+		logf(ctx, "chat completion %s returned %d choices; using the first", resp.ID, len(resp.Choices))
+	}
+	return resp.Choices[0], nil
+}
+
+// isModelNotFound reports whether err says the requested model (or Azure
+// deployment) does not exist, as opposed to any other rejected request.
+func isModelNotFound(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if code, ok := apiErr.Code.(string); ok {
+		switch code {
+		case "model_not_found", "DeploymentNotFound":
+			return true
+		}
+	}
+	return false
+}
+
+// label prefixes output with the action name when LabelOutput is set.
+func (o ActionOptions) label(name CauseBy, output string) string {
+	if !o.LabelOutput {
+		return output
+	}
+	return fmt.Sprintf("// from %s\n%s", name, output)
+}
+
+func contextWindow(a Action) int {
+	if w, ok := a.(ContextWindower); ok && w.ContextWindow() != 0 {
+		return w.ContextWindow()
+	}
+	return 1
+}
 
 type SimpleWriteCode struct {
-	llmClient *openai.Client
+	ActionOptions
+	llmClient LLMProvider
+	// ValidateSyntax runs ValidatePython on the generated code and asks the
+	// model again, up to SyntaxRetries times, if it does not compile.
+	ValidateSyntax bool
+	SyntaxRetries  int
+	// RequiredSignature, e.g. "def product(lst):", is the function the code
+	// must define, as the tests expect it; a reply without it is retried
+	// once with the mismatch explained.
+	RequiredSignature string
+	// NoCodeRetries is how many times a reply without a code fence is
+	// retried with a reformulated prompt, each time at a temperature
+	// TemperatureStep (default 0.3) higher than the last, up to 2. The
+	// ramp starts from the action's temperature, or from the API's
+	// default of 1 when none is set.
+	NoCodeRetries   int
+	TemperatureStep float32
+	// KeepRaw stores the model's full reply, prose around the code
+	// included, in the message's Meta under "raw". Content stays the
+	// extracted code.
+	KeepRaw bool
 }
 
+const defaultTemperatureStep = 0.3
+
+// defaultModelTemperature is what the chat API samples at when a request
+// leaves the temperature unset.
+const defaultModelTemperature = 1.0
+
+// metaRaw is the Message.Meta key holding the unparsed reply of a
+// SimpleWriteCode with KeepRaw set.
+const metaRaw = "raw"
+
 // Name returns the name identifier for the SimpleWriteCode agent type.
-func (a *SimpleWriteCode) Name() string { return "SimpleWriteCode" }
+func (a *SimpleWriteCode) Name() CauseBy { return CauseWriteCode }
+
+func (a *SimpleWriteCode) provider() LLMProvider { return a.llmClient }
 
 func (a *SimpleWriteCode) Run(ctx context.Context, instruction string) (string, error) {
+	return a.run(ctx, instruction, nil)
+}
+
+// RunMessages lets the coder see the images attached to its context, such
+// as a mockup sent with the project idea.
+func (a *SimpleWriteCode) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	var images []string
+	for _, msg := range msgs {
+		images = append(images, msg.Images...)
+	}
+	return a.run(ctx, truncateInput(formatContextFor(ctx, msgs), a.MaxInputBytes), images)
+}
+
+func (a *SimpleWriteCode) run(ctx context.Context, instruction string, images []string) (string, error) {
 	prompt := fmt.Sprintf("Write a python function that can %s.\nReturn ```python\nyour_code_here``` with NO other texts.", instruction)
-	
-	// Azure OpenAI 调用配置
-	req := openai.ChatCompletionRequest{
-		Model: "gpt-4", // 使用部署名称而非模型ID
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+	if a.RequiredSignature != "" {
+		prompt += fmt.Sprintf("\nThe code must define `%s`.", a.RequiredSignature)
 	}
-	
-	resp, err := a.llmClient.CreateChatCompletion(ctx, req)
+
+	synAttempts, sigRetried, noCode := 0, false, 0
+	for {
+		code, fenced, err := a.generate(ctx, prompt, images, noCode)
+		if err != nil {
+			return a.label(a.Name(), code), err
+		}
+		if !fenced && noCode < a.NoCodeRetries && allowRetry(ctx) {
+			noCode++
+			prompt = fmt.Sprintf("%s\nYour previous answer contained no code block. Reply with the code inside ```python and ``` only.", prompt)
+			continue
+		}
+
+		if a.RequiredSignature != "" {
+			if sigErr := checkSignature(code, a.RequiredSignature); sigErr != nil {
+				if sigRetried || !errors.Is(sigErr, ErrSignatureMismatch) || !allowRetry(ctx) {
+					return "", sigErr
+				}
+				sigRetried = true
+				prompt = fmt.Sprintf("%s\nYour previous answer did not define the required function:\n%v", prompt, sigErr)
+				continue
+			}
+		}
+		if !a.ValidateSyntax {
+			return a.label(a.Name(), code), nil
+		}
+
+		synErr := ValidatePython(ctx, code)
+		if synErr == nil || errors.Is(synErr, ErrPythonSkipped) {
+			return a.label(a.Name(), code), nil
+		}
+		if synAttempts >= a.SyntaxRetries || !allowRetry(ctx) {
+			return "", synErr
+		}
+		synAttempts++
+		prompt = fmt.Sprintf("%s\nYour previous answer did not compile:\n%v", prompt, synErr)
+	}
+}
+
+// generate asks for code; retry > 0 marks a retry after a reply without a
+// code fence, sent at a temperature raised by retry steps. fenced reports
+// whether the reply had a fence; without one the whole reply is the code.
+func (a *SimpleWriteCode) generate(ctx context.Context, prompt string, images []string, retry int) (code string, fenced bool, err error) {
+	opts := a.ActionOptions
+	if retry > 0 {
+		base, _, err := opts.sampling()
+		if err != nil {
+			return "", false, err
+		}
+		step := a.TemperatureStep
+		if step <= 0 {
+			step = defaultTemperatureStep
+		}
+		start := float32(defaultModelTemperature)
+		if base != nil {
+			start = *base
+		}
+		opts.Temperature = Ptr(min(start+step*float32(retry), 2))
+	}
+
+	content, err := opts.chatWithImages(ctx, a.llmClient, a.Name(), prompt, images)
 	if err != nil {
-		return "", fmt.Errorf("Azure OpenAI API error: %w", err)
+		return "", false, err
 	}
-	
-	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
-		return "", errors.New("no response from Azure OpenAI")
+	if a.KeepRaw {
+		setMeta(ctx, metaRaw, content)
 	}
-	
-	return parseCode(resp.Choices[0].Message.Content), nil
+	code, _, fenced = findCode(content)
+	if !fenced {
+		code = content
+	}
+	return code, fenced, nil
 }
 
 type SimpleWriteTest struct {
-	llmClient *openai.Client
+	ActionOptions
+	llmClient LLMProvider
 }
 
-func (a *SimpleWriteTest) Name() string { return "SimpleWriteTest" }
+func (a *SimpleWriteTest) Name() CauseBy { return CauseWriteTest }
+
+func (a *SimpleWriteTest) provider() LLMProvider { return a.llmClient }
 
 func (a *SimpleWriteTest) Run(ctx context.Context, contextData string) (string, error) {
 	prompt := fmt.Sprintf("Context: %s\nWrite 3 unit tests using pytest for the given function, assuming you have imported it.\nReturn ```python\nyour_code_here``` with NO other texts.", contextData)
-	
-	req := openai.ChatCompletionRequest{
-		Model: "gpt-4", // 使用部署名称
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
-	}
-	
-	resp, err := a.llmClient.CreateChatCompletion(ctx, req)
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
 	if err != nil {
-		return "", fmt.Errorf("Azure OpenAI API error: %w", err)
-	}
-	
-	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
-		return "", errors.New("no response from Azure OpenAI")
+		return "", err
 	}
-	
-	return parseCode(resp.Choices[0].Message.Content), nil
+	return a.label(a.Name(), parseCode(content)), nil
 }
 
 type SimpleWriteReview struct {
-	llmClient *openai.Client
+	ActionOptions
+	llmClient LLMProvider
+	// AskVerdict has the reviewer end with "VERDICT: APPROVE" or
+	// "VERDICT: REJECT" for a VoteAggregator to count.
+	AskVerdict bool
+	// StructuredComments asks for one `SEVERITY: comment` line per finding
+	// and attaches the parsed comments to the message; see ReviewComments.
+	StructuredComments bool
 }
 
-func (a *SimpleWriteReview) Name() string { return "SimpleWriteReview" }
+func (a *SimpleWriteReview) Name() CauseBy { return CauseWriteReview }
+
+func (a *SimpleWriteReview) provider() LLMProvider { return a.llmClient }
 
 func (a *SimpleWriteReview) Run(ctx context.Context, contextData string) (string, error) {
 	prompt := fmt.Sprintf("Context: %s\nReview the test cases and provide one critical comment:", contextData)
-	
-	req := openai.ChatCompletionRequest{
-		Model: "gpt-4", // 使用部署名称
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+	if a.StructuredComments {
+		prompt += "\n" + structuredReviewInstructions
 	}
-	
-	resp, err := a.llmClient.CreateChatCompletion(ctx, req)
+	if a.AskVerdict {
+		prompt += "\nEnd with a line reading either VERDICT: APPROVE or VERDICT: REJECT."
+	}
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
 	if err != nil {
-		return "", fmt.Errorf("Azure OpenAI API error: %w", err)
+		return "", err
 	}
-	
-	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
-		return "", errors.New("no response from Azure OpenAI")
+	if a.StructuredComments {
+		if data, err := json.Marshal(ParseReviewComments(content)); err == nil {
+			setMeta(ctx, metaReviewComments, string(data))
+		}
 	}
-	
-	return resp.Choices[0].Message.Content, nil
+	return a.label(a.Name(), content), nil
 }
 
 func parseCode(rsp string) string {
-	re := regexp.MustCompile("")
-	matches := re.FindStringSubmatch(rsp)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
-	}
-	
-	// 尝试匹配不带语言标签的代码块
-	re = regexp.MustCompile("")
-	matches = re.FindStringSubmatch(rsp)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
-	}
-	
-	return rsp
+	code, _ := ParseCodeLang(rsp)
+	return code
 }
 
-
 type Role struct {
 	Name      string
 	Profile   string
 	Actions   []Action
-	WatchList []string
-	Memory    *Memory
+	WatchList []CauseBy
+	Memory    MemoryStore
+	// AutoSummarizeAt is an estimated token count; when an action's context
+	// grows beyond it, Act first replaces the memory with a summary written
+	// by Summarizer. Zero disables summarization.
+	AutoSummarizeAt int
+	Summarizer      Action
+	// Clock stamps the messages the role produces; nil uses the wall clock.
+	Clock Clock
+	// IDGenerator names the messages the role produces; nil uses random
+	// IDs. Teams hand their own generator to roles that have none.
+	IDGenerator func() string
+	// Priority decides launch order among roles of the same dependency
+	// wave when Team.MaxConcurrency limits how many run at once; higher
+	// goes first, ties keep their order in Team.Roles.
+	Priority int
+	// ReactDone ends a React loop when it returns true for the latest
+	// message. LoopWindow (default 3) and LoopSimilarity (default 0.95)
+	// tune how React detects a role stuck repeating itself.
+	ReactDone      func(msg Message) bool
+	LoopWindow     int
+	LoopSimilarity float64
+	// Similarity compares outputs for loop detection; nil uses
+	// TokenSimilarity.
+	Similarity func(a, b string) float64
+	// ActionWeights makes Act pick one of Actions at random in proportion
+	// to its weight instead of always the first; Rand, if set, is the
+	// source of that choice so runs can be reproduced from a seed.
+	ActionWeights []float64
+	Rand          *rand.Rand
+	// ContextFormatter turns the context messages into the text the
+	// actions receive, e.g. with each message in <msg role=...> tags; nil
+	// keeps one "[role]: content" line per message.
+	ContextFormatter func(msgs []Message) string
 }
 
-func (r *Role) Act(ctx context.Context) (Message, error) {
+func formatContext(msgs []Message) string {
 	contextData := ""
-	for _, msg := range r.Memory.GetRecent() {
-		contextData += fmt.Sprintf("[%s]: %s\n", msg.Role, msg.Content)
+	for _, msg := range msgs {
+		if tool := ObservedBy(msg); tool != "" {
+			contextData += fmt.Sprintf("[Observation from %s]: %s\n", tool, msg.Content)
+		} else {
+			contextData += fmt.Sprintf("[%s]: %s\n", msg.Role, msg.Content)
+		}
+		for _, att := range msg.Attachments {
+			contextData += fmt.Sprintf("--- attachment %s ---\n%s\n", att.Name, att.Content)
+		}
 	}
+	return contextData
+}
+
+func (r *Role) Act(ctx context.Context) (Message, error) {
+	ctx, done := r.actionContext(ctx)
+	defer done()
+	ctx, span := startSpan(ctx, "role.act")
+	defer span.End()
+	span.SetAttribute("role.name", r.Name)
+
+	for _, action := range r.selectedActions() {
+		recent := r.recentFor(action)
+		if r.AutoSummarizeAt > 0 && r.Summarizer != nil && estimateTokens(r.formatContext(recent)) > r.AutoSummarizeAt {
+			if err := r.summarize(ctx); err != nil {
+				return Message{}, err
+			}
+			recent = r.recentFor(action)
+		}
+
+		contextData := truncateInput(r.formatContext(recent), inputLimit(action))
+		if err := checkContextSize(action, contextData); err != nil {
+			return Message{}, fmt.Errorf("%s action failed: %w", action.Name(), err)
+		}
 
-	for _, action := range r.Actions {
-		output, err := action.Run(ctx, contextData)
+		actCtx, meta := withMeta(WithRequestID(withContextFormatter(ctx, r.ContextFormatter), newMessageID()))
+		setMeta(actCtx, metaRequestID, RequestID(actCtx))
+		var output string
+		var err error
+		if ma, ok := action.(MessageAction); ok {
+			output, err = ma.RunMessages(actCtx, recent)
+		} else {
+			output, err = action.Run(actCtx, contextData)
+		}
+		if cause := stoppedCause(ctx); cause != nil {
+			return Message{}, fmt.Errorf("%s action failed: %w", action.Name(), cause)
+		}
 		if err != nil {
 			return Message{}, fmt.Errorf("%s action failed: %w", action.Name(), err)
 		}
 
-		msg := Message{
-			Content: output,
-			Role:    r.Profile,
-			CauseBy: action.Name(),
+		msg := newMessage(r.Clock, r.IDGenerator, output, r.Profile, action.Name())
+		if cause := meta.producedCause(); cause != "" {
+			msg.CauseBy = cause
+		}
+		msg.Meta = meta.snapshot()
+		if isTool(action) {
+			msg.Role, msg.CauseBy = ToolRole, ObservationCause
+			if msg.Meta == nil {
+				msg.Meta = make(map[string]string)
+			}
+			msg.Meta[metaTool] = string(action.Name())
+		}
+		if len(recent) > 0 {
+			msg.ParentID = recent[len(recent)-1].ID
 		}
 
 		r.Memory.Add(msg)
@@ -191,36 +702,590 @@ func (r *Role) Act(ctx context.Context) (Message, error) {
 	return Message{}, errors.New("no suitable action found")
 }
 
+// ActFromSnapshot runs the role once against a copy of snapshot instead of
+// its own memory, which is left untouched. It is meant for replaying a
+// single role against recorded team state.
+func (r *Role) ActFromSnapshot(ctx context.Context, snapshot []Message) (Message, error) {
+	replay := *r
+	replay.Memory = &Memory{history: append([]Message(nil), snapshot...)}
+	return replay.Act(ctx)
+}
+
 
 type Team struct {
+	mu          sync.Mutex
 	Roles       []*Role
 	ProjectIdea string
+	// Supervisor, if set, is called by RunProjectRounds after every round
+	// with that round's messages. It may call AddRole to grow the team for
+	// the following rounds.
+	Supervisor func(ctx context.Context, t *Team, round []Message)
+	// ShouldStop, if set, is called by RunProjectRounds after every round
+	// with all messages produced so far; returning true ends the run.
+	ShouldStop func(messages []Message) bool
+	// RouteTransform, if set, reshapes each message on its way into a
+	// watcher's memory; from is nil for messages of sub-teams. The
+	// transcript keeps the original.
+	RouteTransform func(from, to *Role, msg Message) Message
+	// MergeStrategy, if set, combines the messages of one wave that share a
+	// CauseBy into the single message routed to their watchers, e.g.
+	// ConcatMessages. The transcript keeps the separate messages.
+	MergeStrategy func(msgs []Message) Message
+	// EarlyStop is checked against each message as soon as its wave
+	// finishes; a match ends the run without running the remaining waves,
+	// e.g. StopWhenTestsPass(nil) to skip review once the tests are green.
+	EarlyStop func(msg Message) bool
+	// SkipUntilWatched skips a role for the round while its memory holds
+	// none of the causes it watches, instead of letting it act on the
+	// project idea alone; it acts in the first round its inputs arrive.
+	SkipUntilWatched bool
+	// MaxDuration caps the wall-clock time of a whole run; zero means no
+	// limit. Roles still working when it elapses are cancelled.
+	MaxDuration time.Duration
+	// MaxConcurrency bounds how many roles act at the same time, including
+	// the roles of sub-teams; zero means no limit.
+	MaxConcurrency int
+	// SubTeams run concurrently before the team's own roles, each on its
+	// own ProjectIdea or, if empty, the parent's. Their outputs are part of
+	// the parent's transcript and are routed to the parent's roles.
+	SubTeams []*Team
+	// Integrator, if set, acts once at the end of a run on everything the
+	// sub-teams and the team's roles produced. Its action should use a
+	// negative ContextMessages to see all of it.
+	Integrator *Role
+	// Validators check invariants of a finished run, e.g.
+	// DefinesFunction("product"); each gets every message the run
+	// produced. Their failures are joined into one ErrValidation error,
+	// returned together with the messages.
+	Validators []func(msgs []Message) error
+	// StreamOnly keeps memory bounded on very large runs: messages are
+	// still printed, passed to OnMessage and routed, but the transcript,
+	// the returned messages and role memories without their own
+	// MaxMessages only keep the newest StreamWindow (default 64).
+	StreamOnly   bool
+	StreamWindow int
+	// RetryBudget caps the retries all actions of a run may make together,
+	// sub-teams included; once spent, failures are returned immediately.
+	// Zero means no cap.
+	RetryBudget int
+	// Clock stamps the seeded project idea; nil uses the wall clock.
+	Clock Clock
+	// IDGenerator names every message of a run, e.g. SequentialIDs("msg")
+	// for golden transcripts; nil uses random IDs.
+	IDGenerator func() string
+	// Output receives the printed transcript; nil means os.Stdout.
+	Output io.Writer
+	// OnRoleState, if set, is called as each role starts and finishes acting.
+	OnRoleState func(r *Role, state RoleState)
+	// OnMessage, if set, is called with every produced message before it is
+	// routed to watchers.
+	OnMessage func(msg Message)
+	// Guard vets the project idea before it is seeded; nil uses
+	// DefaultGuard. Rejections should wrap ErrBlockedInput.
+	Guard func(input string) error
+	// ProjectImages are attached to the seeded project idea, e.g. a UI
+	// mockup. Actions only forward them to models marked with Vision.
+	ProjectImages []string
+	// BroadcastRoster appends a description of the whole team to the
+	// project idea, so every role learns who else works on it.
+	BroadcastRoster bool
+	// SeedMessages script the opening of a run, e.g. a sample PRD. They
+	// precede the project idea in the transcript and are delivered like
+	// the output of the action whose CauseBy they carry: after the idea, to
+	// the roles watching that cause, so it is their latest input. Seeds
+	// caused by UserRequirement are earlier user requests and go to every
+	// role before the idea. Missing IDs and timestamps are filled in.
+	SeedMessages []Message
+	// MaxRoles and MaxActions guard shared deployments against oversized
+	// teams: a run whose roles, or their actions in total, exceed them
+	// fails with *TeamTooLargeError, also when a Supervisor grows the team
+	// past them. Zero means no limit.
+	MaxRoles   int
+	MaxActions int
+	// AllowExecution lets actions such as RunTests execute generated code;
+	// without it they fail with ErrExecutionNotAllowed. ConfirmExecution,
+	// if set, is asked before every execution and may decline it; it can be
+	// called from several roles at once. Sub-teams follow their parent.
+	AllowExecution   bool
+	ConfirmExecution func() bool
+	// PromptPrefix and PromptSuffix are put before and after the prompt of
+	// every LLM call the team's actions make, e.g. for standing safety or
+	// compliance instructions. A sub-team's are nested inside its parent's.
+	PromptPrefix string
+	PromptSuffix string
+	// RollingSummary keeps the cost of long multi-round runs flat: before
+	// each round, every role with a Summarizer folds its messages from
+	// before the last RollingWindow rounds (default 2) into one rolling
+	// summary.
+	RollingSummary bool
+	RollingWindow  int
+	// ContextWarnFraction, if positive, logs a warning after a round for
+	// every role whose history exceeds that fraction of its model's
+	// context window, e.g. 0.8; see ConversationStats.
+	ContextWarnFraction float64
+	// PrintFilter, if set, decides which produced messages are printed to
+	// Output, e.g. PrintOnly("SimpleCoder"). All of them are still routed
+	// and kept in the transcript.
+	PrintFilter func(msg Message) bool
+	// Tracer, if set, records a span for the run, a child span for every
+	// role's Act and below those one per provider call with its token
+	// usage. Sub-teams without their own trace into their parent's.
+	// OpenTelemetry(tr) adapts an OpenTelemetry tracer.
+	Tracer Tracer
+
+	userReq    *Message
+	transcript []Message
+	round      int
+	// roundStarts holds, per role, its memory length at the start of each
+	// round for RollingSummary.
+	roundStarts map[*Role][]int
+	// onRoundStart, set by RunWithTrace, is called before each round.
+	onRoundStart func(round int)
+	// sequential, set by RunSequential, runs the roles one at a time.
+	sequential bool
+	// unpaused is non-nil while the team is paused and closed by Unpause.
+	unpaused chan struct{}
+}
+
+// AddRole adds a role to the team. It is safe to call while the team is
+// running; the role takes part from the next round on. If the project idea
+// has already been seeded, the new role receives it too.
+func (t *Team) AddRole(r *Role) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r.Memory == nil {
+		r.Memory = &Memory{}
+	}
+	if r.IDGenerator == nil {
+		r.IDGenerator = t.IDGenerator
+	}
+	if t.userReq != nil {
+		r.Memory.Add(*t.userReq)
+	}
+	t.Roles = append(t.Roles, r)
+}
+
+func (t *Team) streamWindow() int {
+	if t.StreamWindow > 0 {
+		return t.StreamWindow
+	}
+	return 64
+}
+
+// keep trims msgs to the stream window in StreamOnly mode.
+func (t *Team) keep(msgs []Message) []Message {
+	if !t.StreamOnly || len(msgs) <= t.streamWindow() {
+		return msgs
+	}
+	return append([]Message(nil), msgs[len(msgs)-t.streamWindow():]...)
+}
+
+func (t *Team) record(msgs ...Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.recordLocked(msgs...)
 }
 
-func (t *Team) RunProject(ctx context.Context) {
-	userReq := Message{
-		Content: t.ProjectIdea,
-		Role:    "User",
-		CauseBy: "UserRequirement",
+func (t *Team) recordLocked(msgs ...Message) {
+	t.transcript = t.keep(append(t.transcript, msgs...))
+}
+
+// Transcript returns a copy of every message seen by the team so far: the
+// seeded idea followed by the role outputs in the order they arrived.
+func (t *Team) Transcript() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.transcript...)
+}
+
+// TranscriptForRole returns copies of the transcript messages authored by
+// the role with the given profile, in order.
+func (t *Team) TranscriptForRole(profile string) []Message {
+	var out []Message
+	for _, msg := range t.Transcript() {
+		if msg.Role == profile {
+			out = append(out, msg)
+		}
 	}
+	return out
+}
+
+// BuildThreadTree groups the transcript by ParentID, so tree[id] lists the
+// replies to message id. Messages without a parent are under "".
+func (t *Team) BuildThreadTree() map[string][]Message {
+	tree := make(map[string][]Message)
+	for _, msg := range t.Transcript() {
+		tree[msg.ParentID] = append(tree[msg.ParentID], msg)
+	}
+	return tree
+}
+
+func (t *Team) roles() []*Role {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Role(nil), t.Roles...)
+}
 
+func (t *Team) guard(input string) error {
+	if t.Guard == nil {
+		return DefaultGuard(input)
+	}
+	return t.Guard(input)
+}
+
+func (t *Team) seedIdea() error {
+	if err := t.guard(t.ProjectIdea); err != nil {
+		return err
+	}
+
+	ids := t.IDGenerator
+	if ids == nil {
+		ids = newMessageID
+	}
+	seeds := make([]Message, len(t.SeedMessages))
+	for i, seed := range t.SeedMessages {
+		if seed.CauseBy == "" {
+			return fmt.Errorf("seed message %d has no CauseBy", i)
+		}
+		if seed.ID == "" {
+			seed.ID = ids()
+		}
+		if seed.Timestamp.IsZero() {
+			seed.Timestamp = orRealClock(t.Clock).Now()
+		}
+		seeds[i] = seed
+	}
+
+	t.mu.Lock()
+	idea := t.ProjectIdea
+	if t.BroadcastRoster {
+		idea += "\n\n" + rosterText(t.Roles)
+	}
+	var scripted []Message
+	for _, seed := range seeds {
+		t.recordLocked(seed)
+		if seed.CauseBy != CauseUserRequirement {
+			scripted = append(scripted, seed)
+			continue
+		}
+		for _, role := range t.Roles {
+			role.Memory.Add(seed)
+		}
+	}
+	userReq := newMessage(t.Clock, t.IDGenerator, idea, "User", CauseUserRequirement)
+	userReq.Images = t.ProjectImages
+
+	t.userReq = &userReq
+	t.recordLocked(userReq)
 	for _, role := range t.Roles {
+		if role.IDGenerator == nil {
+			role.IDGenerator = t.IDGenerator
+		}
+		if mem, ok := role.Memory.(*Memory); ok && t.StreamOnly && mem.MaxMessages == 0 {
+			mem.MaxMessages = t.streamWindow()
+		}
 		role.Memory.Add(userReq)
 	}
+	t.mu.Unlock()
 
-	var wg sync.WaitGroup
-	results := make(chan Message, len(t.Roles))
+	for _, seed := range scripted {
+		t.route(nil, seed)
+	}
+	return nil
+}
+
+func rosterText(roles []*Role) string {
+	var b strings.Builder
+	b.WriteString("Team roster:\n")
+	for _, r := range roles {
+		names := make([]CauseBy, 0, len(r.Actions))
+		for _, a := range r.Actions {
+			names = append(names, a.Name())
+		}
+		fmt.Fprintf(&b, "- %s (%s) does %s after %s\n", r.Name, r.Profile, joinCauses(names, ", "), joinCauses(r.WatchList, ", "))
+	}
+	return b.String()
+}
+
+// ErrRunTimeout is returned when a run exceeds Team.MaxDuration.
+var ErrRunTimeout = errors.New("run exceeded its maximum duration")
+
+// RunProject seeds the project idea and runs a single round. Like
+// RunProjectRounds, it returns the messages produced before a cancellation
+// along with the context error.
+func (t *Team) RunProject(ctx context.Context) ([]Message, error) {
+	return t.RunProjectRounds(ctx, 1)
+}
+
+// RunProjectRounds seeds the project idea and then runs the given number of
+// rounds, returning every message produced. If ctx is done or MaxDuration
+// elapses, the messages produced until then are returned together with the
+// context error, or ErrRunTimeout for MaxDuration. A run that completes is
+// then checked by the team's Validators.
+func (t *Team) RunProjectRounds(ctx context.Context, rounds int) ([]Message, error) {
+	msgs, err := t.run(ctx, rounds)
+	if err != nil {
+		return msgs, err
+	}
+	return msgs, t.validate(msgs)
+}
+
+// run is RunProjectRounds without the validation. Sub-teams are run
+// through it too, under their parent's scope.
+func (t *Team) run(ctx context.Context, rounds int) ([]Message, error) {
+	if err := t.checkSize(); err != nil {
+		return nil, err
+	}
+	if err := t.seedIdea(); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.round = 0
+	t.roundStarts = nil
+	t.mu.Unlock()
+
+	ctx, done := t.runScope(ctx)
+	defer done()
+
+	var all []Message
+	if len(t.SubTeams) > 0 {
+		sub, err := t.runSubTeams(ctx, rounds)
+		all = t.keep(append(all, sub...))
+		if err != nil {
+			return all, err
+		}
+	}
+
+	own, err := t.runRounds(ctx, rounds)
+	all = t.keep(append(all, own...))
+	if err != nil || t.Integrator == nil {
+		return all, err
+	}
+
+	msg, err := t.integrate(ctx, all)
+	if err != nil {
+		return all, err
+	}
+	return t.keep(append(all, msg)), nil
+}
+
+// Resume continues a run, e.g. one restored by LoadCheckpoint, from the
+// last completed round until rounds rounds have run in total. The project
+// idea is not seeded again.
+func (t *Team) Resume(ctx context.Context, rounds int) ([]Message, error) {
+	ctx, done := t.runScope(ctx)
+	defer done()
+	return t.runRounds(ctx, rounds)
+}
+
+// Continue extends a finished run with a new user instruction: it is added
+// to every role's memory, next to the history they already have, and one
+// more round is run. The instruction is vetted by the team's guard.
+func (t *Team) Continue(ctx context.Context, instruction string) ([]Message, error) {
+	if err := t.guard(instruction); err != nil {
+		return nil, err
+	}
 
+	msg := newMessage(t.Clock, t.IDGenerator, instruction, "User", CauseUserRequirement)
+	t.mu.Lock()
+	t.recordLocked(msg)
 	for _, role := range t.Roles {
+		role.Memory.Add(msg)
+	}
+	t.mu.Unlock()
+
+	ctx, done := t.runScope(ctx)
+	defer done()
+	return t.runRounds(ctx, t.Round()+1)
+}
+
+// runScope sets up the context every run entry point acts under: the
+// MaxDuration deadline, the retry budget, the execution policy, the prompt
+// frame, the MaxConcurrency limit and the run's span. done ends the span
+// and releases the context.
+func (t *Team) runScope(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := t.runContext(ctx)
+	ctx = withConcurrencyLimit(ctx, t.MaxConcurrency)
+	ctx = withRetryBudget(ctx, t.RetryBudget)
+	ctx = withExecutionPolicy(ctx, t.AllowExecution, t.ConfirmExecution)
+	ctx = withPromptFrame(ctx, t.PromptPrefix, t.PromptSuffix)
+	ctx, span := startSpan(withTracer(ctx, t.Tracer), "team.run")
+	return ctx, func() {
+		span.End()
+		cancel()
+	}
+}
+
+// runContext applies MaxDuration to ctx.
+func (t *Team) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.MaxDuration > 0 {
+		return context.WithTimeoutCause(ctx, t.MaxDuration, ErrRunTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (t *Team) runRounds(ctx context.Context, rounds int) ([]Message, error) {
+	var all []Message
+	for t.Round() < rounds {
+		if t.waitIfPaused(ctx) != nil || ctx.Err() != nil {
+			break
+		}
+		if err := t.checkSize(); err != nil {
+			return all, err
+		}
+		t.mu.Lock()
+		hook := t.onRoundStart
+		t.mu.Unlock()
+		if hook != nil {
+			hook(t.Round())
+		}
+		if t.RollingSummary {
+			t.rollSummaries(ctx)
+		}
+		produced, stop := t.runRound(ctx)
+		all = t.keep(append(all, produced...))
+		t.mu.Lock()
+		t.round++
+		t.mu.Unlock()
+
+		t.warnLongConversations()
+		if stop {
+			break
+		}
+		if t.Supervisor != nil {
+			t.Supervisor(ctx, t, produced)
+		}
+		if t.ShouldStop != nil && t.ShouldStop(all) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return all, context.Cause(ctx)
+	}
+	return all, nil
+}
+
+// Round returns the number of rounds completed in the current run.
+func (t *Team) Round() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.round
+}
+
+// runRound lets every role act once and routes the outputs to the roles
+// watching them. Roles run in dependency order, so a producer always acts
+// before its consumers regardless of their order in Roles; roles with no
+// dependency between them run concurrently.
+//
+// If EarlyStop matches a message, the remaining waves are skipped and stop
+// is true.
+func (t *Team) runRound(ctx context.Context) (produced []Message, stop bool) {
+	t.mu.Lock()
+	sequential := t.sequential
+	t.mu.Unlock()
+	waves := dependencyWaves(t.roles())
+	if sequential {
+		waves = turns(t.roles())
+	}
+	for _, wave := range waves {
+		if ctx.Err() != nil {
+			break
+		}
+		msgs := t.runWave(ctx, wave)
+		produced = append(produced, msgs...)
+		if t.EarlyStop != nil {
+			for _, msg := range msgs {
+				if t.EarlyStop(msg) {
+					return produced, true
+				}
+			}
+		}
+	}
+	return produced, false
+}
+
+func (t *Team) output() io.Writer {
+	if t.Output == nil {
+		return os.Stdout
+	}
+	return t.Output
+}
+
+// print writes msg to the team's output unless PrintFilter rejects it.
+func (t *Team) print(msg Message) {
+	if t.PrintFilter != nil && !t.PrintFilter(msg) {
+		return
+	}
+	fmt.Fprintf(t.output(), "=== [%s] OUTPUT ===\n%s\n\n", msg.Role, msg.Content)
+}
+
+// PrintOnly returns a Team.PrintFilter that prints only the messages of
+// roles with the given profiles.
+func PrintOnly(profiles ...string) func(Message) bool {
+	return func(msg Message) bool {
+		for _, p := range profiles {
+			if msg.Role == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (t *Team) setState(r *Role, state RoleState) {
+	if t.OnRoleState != nil {
+		t.OnRoleState(r, state)
+	}
+}
+
+type roleOutput struct {
+	role *Role
+	msg  Message
+}
+
+func (t *Team) runWave(ctx context.Context, roles []*Role) []Message {
+	var wg sync.WaitGroup
+	results := make(chan roleOutput, len(roles))
+
+	slots := concurrencySlots(ctx)
+
+	// Slots are taken here rather than in the goroutines so that roles start
+	// strictly in priority order. Once ctx is done no more roles start, and
+	// the messages of those that finished are still returned.
+launch:
+	for _, role := range byRolePriority(roles) {
+		if ctx.Err() != nil {
+			break
+		}
+		if t.SkipUntilWatched && !role.hasWatched() {
+			continue
+		}
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				break launch
+			}
+		}
 		wg.Add(1)
 		go func(r *Role) {
 			defer wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			t.setState(r, RoleRunning)
 			msg, err := r.Act(ctx)
+			for errors.Is(err, errRoleRestart) && ctx.Err() == nil {
+				msg, err = r.Act(ctx)
+			}
 			if err != nil {
-				fmt.Printf("%s error: %v\n", r.Profile, err)
+				t.setState(r, RoleFailed)
+				fmt.Fprintf(t.output(), "%s error: %v\n", r.Profile, err)
 				return
 			}
-			results <- msg
+			t.setState(r, RoleDone)
+			results <- roleOutput{r, msg}
 		}(role)
 	}
 
@@ -229,20 +1294,73 @@ func (t *Team) RunProject(ctx context.Context) {
 		close(results)
 	}()
 
-	for msg := range results {
-		fmt.Printf("=== [%s] OUTPUT ===\n%s\n\n", msg.Role, msg.Content)
-		
-		for _, role := range t.Roles {
-			for _, watchType := range role.WatchList {
-				if watchType == msg.CauseBy {
-					role.Memory.Add(msg)
+	var produced []Message
+	producers := make(map[string]*Role, len(roles))
+	for out := range results {
+		msg := out.msg
+		producers[msg.ID] = out.role
+		if t.OnMessage != nil {
+			t.OnMessage(msg)
+		}
+		t.print(msg)
+		produced = append(produced, msg)
+		t.record(msg)
+	}
+
+	// Consumers run in later waves, so routing waits for the whole wave and
+	// delivers in priority order: the most urgent message ends up newest in
+	// each watcher's memory and is the one a default-window action sees.
+	for _, msg := range byPriority(mergeByCause(produced, t.MergeStrategy), producers) {
+		t.route(producers[msg.ID], msg)
+	}
+	return produced
+}
+
+// route delivers msg to every role watching its cause, through
+// RouteTransform if set. from is the producing role, or nil when the
+// message comes from outside the team's roles.
+func (t *Team) route(from *Role, msg Message) {
+	for _, role := range t.roles() {
+		for _, watchType := range role.WatchList {
+			if watchType == msg.CauseBy {
+				delivered := msg
+				if t.RouteTransform != nil {
+					delivered = t.RouteTransform(from, role, msg)
 				}
+				role.Memory.Add(delivered)
 			}
 		}
 	}
 }
 
+// hasWatched reports whether r's memory holds a message of a cause r
+// watches.
+func (r *Role) hasWatched() bool {
+	for _, cause := range r.WatchList {
+		if len(r.Memory.GetByCauseBy(cause, 1)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
+	showProgress := flag.Bool("progress", false, "show which roles are working (terminal only)")
+	check := flag.Bool("check", false, "verify the LLM credentials and connectivity before running")
+	ideaFlag := flag.String("idea", "", "the project idea")
+	ideaFile := flag.String("idea-file", "", "read the project idea from a file, or from stdin if it is - (used when -idea is empty)")
+	flag.Parse()
+
+	idea, err := readProjectIdea(*ideaFlag, *ideaFile, os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, err := llmEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	apiKey := "" // Azure API密钥
 	azureEndpoint := "https://azure-openai-wus3.openai.azure.com/" // Azure终结点
 	
@@ -253,44 +1371,43 @@ func main() {
 		return "gpt-4" // 使用您在Azure门户中创建的部署名称
 	}
 	
-	llmClient := openai.NewClientWithConfig(config)
+	llmClient := Named("azure-openai", NewOpenAIProvider(config))
+
+	if *check {
+		if err := CheckProvider(context.Background(), llmClient); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
 	// 创建角色
-	coder := &Role{
-		Name:    "Alice",
-		Profile: "SimpleCoder",
-		Actions: []Action{
-			&SimpleWriteCode{llmClient: llmClient},
-		},
-		WatchList: []string{"UserRequirement"},
-		Memory:    &Memory{},
-	}
-
-	tester := &Role{
-		Name:    "Bob",
-		Profile: "SimpleTester",
-		Actions: []Action{
-			&SimpleWriteTest{llmClient: llmClient},
-		},
-		WatchList: []string{"SimpleWriteCode"},
-		Memory:    &Memory{},
-	}
-
-	reviewer := &Role{
-		Name:    "Charlie",
-		Profile: "SimpleReviewer",
-		Actions: []Action{
-			&SimpleWriteReview{llmClient: llmClient},
-		},
-		WatchList: []string{"SimpleWriteTest"},
-		Memory:    &Memory{},
+	var roles []*Role
+	for _, name := range []string{"Coder", "Tester", "Reviewer"} {
+		role, err := PresetRole(name, llmClient)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		roles = append(roles, role)
 	}
 
 	// 创建团队并运行项目
 	team := Team{
-		Roles: []*Role{ tester,coder, reviewer},
-		ProjectIdea: "write a function that calculates the product of a list",
+		Roles: roles,
+		ProjectIdea: idea,
+	}
+
+	var progress *Progress
+	if *showProgress {
+		progress = NewProgress(os.Stderr)
+		progress.Attach(&team)
+		team.Output = progress.Wrap(os.Stdout)
 	}
 
-	team.RunProject(context.Background())
+	_, err = team.RunProject(context.Background())
+	progress.Stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryStore is what roles need from their memory. Memory, guarded by a
+// single mutex, suits the usual one-memory-per-role setup where reads are
+// as frequent as writes. ShardedMemory suits a memory shared as a
+// blackboard by many roles writing concurrently, at the price of slower
+// reads, which have to merge all shards. The Add and Search benchmarks
+// in main_test.go measure both: a Search over 10k messages takes about
+// 2.5 times as long on a ShardedMemory, while Adds only gain once several
+// cores add at the same time (compare go test -bench Add -cpu 1,8 on a
+// multi-core machine).
+type MemoryStore interface {
+	Add(msg Message)
+	GetRecentN(n int) []Message
+	GetByCauseBy(causeBy CauseBy, n int) []Message
+	Search(pattern string) ([]Message, error)
+	Compact(n int, summary Message)
+}
+
+// ShardedMemory spreads its history over several independently locked
+// shards. Every message gets a global sequence number on Add, and reads
+// merge the shards back into that order.
+type ShardedMemory struct {
+	seq    atomic.Uint64
+	shards []memoryShard
+}
+
+type memoryShard struct {
+	mu      sync.Mutex
+	entries []seqMessage
+}
+
+type seqMessage struct {
+	seq uint64
+	msg Message
+}
+
+// NewShardedMemory returns a ShardedMemory with n shards; n < 1 means 1.
+func NewShardedMemory(n int) *ShardedMemory {
+	if n < 1 {
+		n = 1
+	}
+	return &ShardedMemory{shards: make([]memoryShard, n)}
+}
+
+func (m *ShardedMemory) Add(msg Message) {
+	seq := m.seq.Add(1)
+	sh := &m.shards[seq%uint64(len(m.shards))]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	// A concurrent Add holding a smaller seq for this shard may arrive
+	// late; keep the shard sorted.
+	i := len(sh.entries)
+	sh.entries = append(sh.entries, seqMessage{})
+	for i > 0 && sh.entries[i-1].seq > seq {
+		sh.entries[i] = sh.entries[i-1]
+		i--
+	}
+	sh.entries[i] = seqMessage{seq, msg}
+}
+
+// GetRecentN returns a copy of the last n messages in global order. A
+// negative n returns the whole history.
+func (m *ShardedMemory) GetRecentN(n int) []Message {
+	all := m.merged()
+	if n < 0 || n > len(all) {
+		n = len(all)
+	}
+	if n == 0 {
+		return nil
+	}
+	out := make([]Message, n)
+	for i, e := range all[len(all)-n:] {
+		out[i] = e.msg
+	}
+	return out
+}
+
+// GetByCauseBy returns copies of the newest n messages caused by causeBy,
+// oldest first; a negative n returns all of them.
+func (m *ShardedMemory) GetByCauseBy(causeBy CauseBy, n int) []Message {
+	return latestByCause(m.GetRecentN(-1), causeBy, n)
+}
+
+// Search returns copies of the messages whose content matches the regular
+// expression pattern, oldest first.
+func (m *ShardedMemory) Search(pattern string) ([]Message, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("memory search: %w", err)
+	}
+	var out []Message
+	for _, e := range m.merged() {
+		if re.MatchString(e.msg.Content) {
+			out = append(out, e.msg)
+		}
+	}
+	return out, nil
+}
+
+// Compact replaces the oldest n messages with summary, keeping anything
+// added after them.
+func (m *ShardedMemory) Compact(n int, summary Message) {
+	m.lockAll()
+	defer m.unlockAll()
+
+	all := m.mergeLocked()
+	if n > len(all) {
+		n = len(all)
+	}
+	var cutoff uint64
+	if n > 0 {
+		cutoff = all[n-1].seq
+	}
+	for i := range m.shards {
+		sh := &m.shards[i]
+		kept := sh.entries[:0]
+		for _, e := range sh.entries {
+			if e.seq > cutoff {
+				kept = append(kept, e)
+			}
+		}
+		sh.entries = kept
+	}
+	// The summary takes the place of the newest compacted message, so it
+	// sorts before everything that was kept.
+	sh := &m.shards[cutoff%uint64(len(m.shards))]
+	sh.entries = append([]seqMessage{{cutoff, summary}}, sh.entries...)
+}
+
+// replace makes msgs the whole history, in order.
+func (m *ShardedMemory) replace(msgs []Message) {
+	m.lockAll()
+	defer m.unlockAll()
+	for i := range m.shards {
+		m.shards[i].entries = nil
+	}
+	for i, msg := range msgs {
+		seq := uint64(i + 1)
+		sh := &m.shards[seq%uint64(len(m.shards))]
+		sh.entries = append(sh.entries, seqMessage{seq, msg})
+	}
+	m.seq.Store(uint64(len(msgs)))
+}
+
+func (m *ShardedMemory) lockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.Lock()
+	}
+}
+
+func (m *ShardedMemory) unlockAll() {
+	for i := range m.shards {
+		m.shards[i].mu.Unlock()
+	}
+}
+
+func (m *ShardedMemory) merged() []seqMessage {
+	m.lockAll()
+	defer m.unlockAll()
+	return m.mergeLocked()
+}
+
+// mergeLocked k-way merges the shards, each already sorted by seq. It must
+// be called with every shard locked.
+func (m *ShardedMemory) mergeLocked() []seqMessage {
+	total := 0
+	for i := range m.shards {
+		total += len(m.shards[i].entries)
+	}
+	out := make([]seqMessage, 0, total)
+	pos := make([]int, len(m.shards))
+	for len(out) < total {
+		best := -1
+		for i := range m.shards {
+			if pos[i] < len(m.shards[i].entries) && (best < 0 || m.shards[i].entries[pos[i]].seq < m.shards[best].entries[pos[best]].seq) {
+				best = i
+			}
+		}
+		out = append(out, m.shards[best].entries[pos[best]])
+		pos[best]++
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package main
+
+import "strings"
+
+// mergeByCause replaces every group of messages sharing a CauseBy with the
+// single message merge makes of it, placed where the group's first message
+// was. Without merge, msgs is returned as is.
+func mergeByCause(msgs []Message, merge func([]Message) Message) []Message {
+	if merge == nil {
+		return msgs
+	}
+	groups := make(map[CauseBy][]Message)
+	for _, msg := range msgs {
+		groups[msg.CauseBy] = append(groups[msg.CauseBy], msg)
+	}
+
+	var merged []Message
+	for _, msg := range msgs {
+		group, ok := groups[msg.CauseBy]
+		if !ok {
+			continue
+		}
+		delete(groups, msg.CauseBy)
+		if len(group) == 1 {
+			merged = append(merged, msg)
+		} else {
+			merged = append(merged, merge(group))
+		}
+	}
+	return merged
+}
+
+// ConcatMessages is a Team.MergeStrategy that joins the contents of msgs,
+// each under its author's name, into one message with the ID and cause of
+// the first and the highest priority among them.
+func ConcatMessages(msgs []Message) Message {
+	merged := msgs[0]
+	merged.Meta = nil
+	parts := make([]string, len(msgs))
+	for i, msg := range msgs {
+		parts[i] = "[" + msg.Role + "]:\n" + msg.Content
+		if msg.Priority > merged.Priority {
+			merged.Priority = msg.Priority
+		}
+	}
+	merged.Content = strings.Join(parts, "\n\n")
+	return merged
+}
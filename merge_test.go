@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConcatMessages(t *testing.T) {
+	a := Message{ID: "a", Role: "CoderA", Content: "def f(): pass", CauseBy: CauseWriteCode, Meta: map[string]string{"k": "v"}}
+	b := Message{ID: "b", Role: "CoderB", Content: "def g(): pass", CauseBy: CauseWriteCode, Priority: 2}
+	got := mergeByCause([]Message{a, {ID: "r", CauseBy: CauseWriteReview}, b}, ConcatMessages)
+	if len(got) != 2 || got[0].ID != "a" || got[1].ID != "r" {
+		t.Fatalf("merged to %+v", got)
+	}
+	m := got[0]
+	if m.Content != "[CoderA]:\ndef f(): pass\n\n[CoderB]:\ndef g(): pass" || m.Priority != 2 || m.Meta != nil {
+		t.Errorf("merged message %+v", m)
+	}
+	if got := mergeByCause([]Message{a, b}, nil); len(got) != 2 {
+		t.Errorf("no strategy merged %d messages", 2-len(got))
+	}
+}
+
+func TestMergeStrategyInTeam(t *testing.T) {
+	for _, merge := range []bool{false, true} {
+		llm := newPipelineProvider()
+		team := newPipelineTeam(t, llm)
+		coderB := &Role{Name: "Ada", Profile: "SecondCoder", Actions: []Action{&SimpleWriteCode{llmClient: llm}},
+			WatchList: []CauseBy{CauseUserRequirement}, Memory: &Memory{}}
+		team.Roles = append(team.Roles[:1], append([]*Role{coderB}, team.Roles[1:]...)...)
+		team.Output = io.Discard
+		if merge {
+			team.MergeStrategy = ConcatMessages
+		}
+		if _, err := team.RunProject(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var testerPrompt string
+		for _, p := range llm.prompts() {
+			if strings.Contains(p, "unit tests") {
+				testerPrompt = p
+			}
+		}
+		both := strings.Contains(testerPrompt, "[SimpleCoder]") && strings.Contains(testerPrompt, "[SecondCoder]")
+		if both != merge {
+			t.Errorf("merge %v: tester saw both coders: %v\n%s", merge, both, testerPrompt)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// NewMessage builds a message with a fresh random ID, stamped with the
+// current time of clock. A nil clock uses the wall clock.
+func NewMessage(clock Clock, content, role string, causeBy CauseBy) Message {
+	return newMessage(clock, nil, content, role, causeBy)
+}
+
+// newMessage is NewMessage with an ID generator; nil uses random IDs.
+func newMessage(clock Clock, ids func() string, content, role string, causeBy CauseBy) Message {
+	if ids == nil {
+		ids = newMessageID
+	}
+	return Message{
+		ID:        ids(),
+		Content:   content,
+		Role:      role,
+		CauseBy:   causeBy,
+		Timestamp: orRealClock(clock).Now(),
+	}
+}
+
+func newMessageID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// SequentialIDs returns a goroutine-safe ID generator yielding prefix-1,
+// prefix-2, ... for reproducible transcripts.
+func SequentialIDs(prefix string) func() string {
+	var n atomic.Int64
+	return func() string {
+		return fmt.Sprintf("%s-%d", prefix, n.Add(1))
+	}
+}
+
+// compareIDs orders message IDs, comparing a trailing sequence number such
+// as SequentialIDs produce numerically, so that "msg-2" sorts before
+// "msg-10".
+func compareIDs(a, b string) int {
+	ap, an := splitSequence(a)
+	bp, bn := splitSequence(b)
+	if ap != bp || an == "" || bn == "" {
+		return strings.Compare(a, b)
+	}
+	if len(an) != len(bn) {
+		return cmp.Compare(len(an), len(bn))
+	}
+	return strings.Compare(an, bn)
+}
+
+// splitSequence splits id into its prefix and trailing digits, without
+// leading zeros.
+func splitSequence(id string) (prefix, digits string) {
+	i := len(id)
+	for i > 0 && id[i-1] >= '0' && id[i-1] <= '9' {
+		i--
+	}
+	digits = strings.TrimLeft(id[i:], "0")
+	if digits == "" && i < len(id) {
+		digits = "0"
+	}
+	return id[:i], digits
+}
+
+// ImageDataURL encodes image bytes as a data: URL usable in Message.Images.
+func ImageDataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
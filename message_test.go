@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestSequentialIDsAcrossRun(t *testing.T) {
+	run := func() []string {
+		team := newPipelineTeam(t, newPipelineProvider())
+		team.IDGenerator = SequentialIDs("msg")
+		if _, err := team.RunProject(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, msg := range team.Transcript() {
+			ids = append(ids, msg.ID)
+		}
+		return ids
+	}
+	want := []string{"msg-1", "msg-2", "msg-3", "msg-4"}
+	for i := 0; i < 3; i++ {
+		if got := run(); !slices.Equal(got, want) {
+			t.Fatalf("run %d: IDs %v, want %v", i, got, want)
+		}
+	}
+
+	team := newPipelineTeam(t, newPipelineProvider())
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if id := team.Transcript()[0].ID; len(id) != 16 {
+		t.Errorf("default ID %q is not a random hex ID", id)
+	}
+}
+
+func TestSequentialIDsAreUnique(t *testing.T) {
+	next := SequentialIDs("m")
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				id := next()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 1000 || !seen["m-1"] || !seen["m-1000"] {
+		t.Errorf("got %d distinct IDs", len(seen))
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	ids := []string{"msg-10", "msg-2", "msg-1", "abc", "msg-010", "msg-9"}
+	sort.SliceStable(ids, func(i, j int) bool { return compareIDs(ids[i], ids[j]) < 0 })
+	if want := []string{"abc", "msg-1", "msg-2", "msg-9", "msg-10", "msg-010"}; !slices.Equal(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// messageMeta collects the metadata an action records while Role.Act runs
+// it; it ends up in the produced Message's Meta.
+type messageMeta struct {
+	mu     sync.Mutex
+	values map[string]string
+	usage  openai.Usage
+	cost   float64
+	cause  CauseBy
+}
+
+type metaKey struct{}
+
+func withMeta(ctx context.Context) (context.Context, *messageMeta) {
+	m := &messageMeta{}
+	return context.WithValue(ctx, metaKey{}, m), m
+}
+
+// setMeta records key=value on the message being produced. It is a no-op
+// outside Role.Act.
+func setMeta(ctx context.Context, key, value string) {
+	m, ok := ctx.Value(metaKey{}).(*messageMeta)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values == nil {
+		m.values = make(map[string]string)
+	}
+	m.values[key] = value
+}
+
+// setCause makes cause the CauseBy of the message being produced, which
+// must be one of the running action's Causes. It is a no-op outside
+// Role.Act.
+func setCause(ctx context.Context, cause CauseBy) {
+	m, ok := ctx.Value(metaKey{}).(*messageMeta)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cause = cause
+}
+
+func (m *messageMeta) producedCause() CauseBy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cause
+}
+
+func (m *messageMeta) snapshot() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.values) == 0 && m.usage.PromptTokens == 0 && m.usage.CompletionTokens == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m.values)+3)
+	for k, v := range m.values {
+		out[k] = v
+	}
+	if m.usage.PromptTokens > 0 || m.usage.CompletionTokens > 0 {
+		out[metaPromptTokens] = strconv.Itoa(m.usage.PromptTokens)
+		out[metaCompletionTokens] = strconv.Itoa(m.usage.CompletionTokens)
+		out[metaCostUSD] = strconv.FormatFloat(m.cost, 'f', 6, 64)
+	}
+	return out
+}
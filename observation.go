@@ -0,0 +1,49 @@
+package main
+
+// Messages produced by tools — actions that execute something rather than
+// ask a model — are observations: they have Role ToolRole and CauseBy
+// ObservationCause, and their Meta names the tool under metaTool. Roles
+// watch ObservationCause to receive them.
+const (
+	ObservationCause CauseBy = "Observation"
+	ToolRole                 = "Tool"
+
+	metaTool = "tool"
+)
+
+// ToolAction is implemented by actions whose output Role.Act should record
+// as an observation instead of as the role's own message.
+type ToolAction interface {
+	IsTool() bool
+}
+
+func isTool(a Action) bool {
+	t, ok := a.(ToolAction)
+	return ok && t.IsTool()
+}
+
+// CausesAction is implemented by actions whose messages carry one of
+// several causes, picked on every run, instead of the action's Name.
+type CausesAction interface {
+	Causes() []CauseBy
+}
+
+// producedCauses are the CauseBy values of the messages a produces.
+func producedCauses(a Action) []CauseBy {
+	if isTool(a) {
+		return []CauseBy{ObservationCause}
+	}
+	if c, ok := a.(CausesAction); ok {
+		return c.Causes()
+	}
+	return []CauseBy{a.Name()}
+}
+
+// ObservedBy returns the name of the tool that produced msg, or "" if msg
+// is not an observation.
+func ObservedBy(msg Message) CauseBy {
+	if msg.CauseBy != ObservationCause {
+		return ""
+	}
+	return CauseBy(msg.Meta[metaTool])
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestObservationsRoutedAndLabeled(t *testing.T) {
+	fakeInterpreter(t, "echo '.'; echo '1 passed in 0.01s'")
+	var out bytes.Buffer
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.Output = &out
+	team.Roles[2].WatchList = []CauseBy{ObservationCause}
+	team.AllowExecution = true
+	team.AddRole(&Role{Name: "Runner", Profile: "TestRunner", Actions: []Action{&RunTests{}}, WatchList: []CauseBy{CauseWriteCode, CauseWriteTest}})
+
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var obs []Message
+	for _, msg := range team.Transcript() {
+		if msg.CauseBy == ObservationCause {
+			obs = append(obs, msg)
+		}
+	}
+	if len(obs) != 1 {
+		t.Fatalf("transcript holds %d observations, want 1", len(obs))
+	}
+	o := obs[0]
+	if o.Role != ToolRole || ObservedBy(o) != CauseTestRun || !strings.HasPrefix(o.Content, "exit code: 0\n") {
+		t.Errorf("observation %+v", o)
+	}
+	if !strings.Contains(out.String(), "=== [Tool] OUTPUT ===\nexit code: 0") {
+		t.Errorf("observation not labeled as a tool's in the output:\n%s", out.String())
+	}
+
+	var reviewer []Message
+	for _, msg := range team.Roles[2].Memory.GetRecentN(-1) {
+		if msg.CauseBy == ObservationCause {
+			reviewer = append(reviewer, msg)
+		}
+	}
+	if len(reviewer) != 1 || reviewer[0].ID != o.ID {
+		t.Errorf("reviewer got observations %v", reviewer)
+	}
+	if got := causes(team.Roles[1].Memory.GetRecentN(-1)); slices.Contains(got, ObservationCause) {
+		t.Errorf("tester, not watching observations, got %v", got)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenTelemetry adapts tr to a Tracer, so Team.Tracer can export the run's
+// spans through an OpenTelemetry pipeline.
+func OpenTelemetry(tr trace.Tracer) Tracer {
+	return otelTracer{tr}
+}
+
+type otelTracer struct {
+	tr trace.Tracer
+}
+
+func (t otelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := t.tr.Start(ctx, name)
+	return ctx, otelSpan{span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttribute(key string, value any) {
+	s.span.SetAttributes(otelAttribute(key, value))
+}
+
+func (s otelSpan) End() { s.span.End() }
+
+// otelAttribute converts value to the closest attribute type, falling back
+// to its printed form.
+func otelAttribute(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOpenTelemetrySpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.Tracer = OpenTelemetry(tp.Tracer("test"))
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	byID := map[string]sdktrace.ReadOnlySpan{}
+	var roots, acts, chats int
+	for _, s := range spans {
+		byID[s.SpanContext().SpanID().String()] = s
+	}
+	parent := func(s sdktrace.ReadOnlySpan) string {
+		if p, ok := byID[s.Parent().SpanID().String()]; ok && s.Parent().IsValid() {
+			return p.Name()
+		}
+		return ""
+	}
+	for _, s := range spans {
+		switch s.Name() {
+		case "team.run":
+			roots++
+			if s.Parent().IsValid() {
+				t.Errorf("team.run has a parent")
+			}
+		case "role.act":
+			acts++
+			if got := parent(s); got != "team.run" {
+				t.Errorf("role.act parent = %q, want team.run", got)
+			}
+		case "llm.chat":
+			chats++
+			if got := parent(s); got != "role.act" {
+				t.Errorf("llm.chat parent = %q, want role.act", got)
+			}
+			if !hasAttribute(s.Attributes(), attribute.Int("llm.total_tokens", 150)) {
+				t.Errorf("llm.chat attributes %v lack the token usage", s.Attributes())
+			}
+		}
+	}
+	if roots != 1 || acts != 3 || chats != 3 {
+		t.Errorf("got %d team.run, %d role.act, %d llm.chat spans; want 1, 3, 3", roots, acts, chats)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package main
+
+import "context"
+
+// Pause stops the team from starting new rounds. Roles already acting
+// finish their round first; the run then waits, with all its state, until
+// Unpause. (Resume is taken by restoring a checkpoint.) Time spent paused
+// still counts towards MaxDuration.
+func (t *Team) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.unpaused == nil {
+		t.unpaused = make(chan struct{})
+	}
+}
+
+// Unpause lets a paused team continue with its next round.
+func (t *Team) Unpause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.unpaused != nil {
+		close(t.unpaused)
+		t.unpaused = nil
+	}
+}
+
+// Paused reports whether Pause is in effect.
+func (t *Team) Paused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.unpaused != nil
+}
+
+// waitIfPaused blocks while the team is paused or until ctx is done.
+func (t *Team) waitIfPaused(ctx context.Context) error {
+	t.mu.Lock()
+	unpaused := t.unpaused
+	t.mu.Unlock()
+	if unpaused == nil {
+		return nil
+	}
+	select {
+	case <-unpaused:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pausingTeam is a bugTeam that pauses itself after round 2 and reports
+// it on paused.
+func pausingTeam() (*Team, chan struct{}) {
+	team := bugTeam(100)
+	paused := make(chan struct{})
+	team.Supervisor = func(ctx context.Context, team *Team, produced []Message) {
+		if team.Round() == 2 {
+			team.Pause()
+			close(paused)
+		}
+	}
+	return team, paused
+}
+
+func TestPausePreservesProgress(t *testing.T) {
+	team, paused := pausingTeam()
+	type result struct {
+		msgs []Message
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		msgs, err := team.RunProjectRounds(context.Background(), 5)
+		done <- result{msgs, err}
+	}()
+
+	<-paused
+	select {
+	case <-done:
+		t.Fatal("paused run finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if !team.Paused() || team.Round() != 2 {
+		t.Fatalf("paused %v at round %d, want paused at round 2", team.Paused(), team.Round())
+	}
+
+	team.Unpause()
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if team.Paused() || team.Round() != 5 {
+		t.Errorf("paused %v at round %d after the run", team.Paused(), team.Round())
+	}
+	if len(res.msgs) != 5 {
+		t.Fatalf("got %d messages, want 5", len(res.msgs))
+	}
+	for i, msg := range res.msgs {
+		if want := fmt.Sprintf("step %d", i); msg.Content != want {
+			t.Errorf("message %d is %q, want %q", i, msg.Content, want)
+		}
+	}
+}
+
+func TestPausedRunHonoursContext(t *testing.T) {
+	team, paused := pausingTeam()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-paused
+		cancel()
+	}()
+	msgs, err := team.RunProjectRounds(ctx, 5)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want the cancellation", err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("got %d messages, want the 2 from before the pause", len(msgs))
+	}
+}
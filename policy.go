@@ -0,0 +1,62 @@
+package main
+
+// ContextPolicer is implemented by actions that pick their context per
+// cause instead of as the latest messages; see ActionOptions.ContextPolicy.
+type ContextPolicer interface {
+	ContextByCause() map[CauseBy]int
+}
+
+func (o ActionOptions) ContextByCause() map[CauseBy]int { return o.ContextPolicy }
+
+// contextPolicy returns the action's per-cause policy, or nil.
+func contextPolicy(a Action) map[CauseBy]int {
+	if p, ok := a.(ContextPolicer); ok {
+		return p.ContextByCause()
+	}
+	return nil
+}
+
+// recentFor returns the memory messages the action sees: those selected by
+// its ContextPolicy if it has one, else the latest ContextWindow ones.
+func (r *Role) recentFor(a Action) []Message {
+	policy := contextPolicy(a)
+	if len(policy) == 0 {
+		return r.Memory.GetRecentN(contextWindow(a))
+	}
+	return selectByPolicy(r.Memory.GetRecentN(-1), policy)
+}
+
+// selectByPolicy keeps, for every cause in policy, its newest policy[cause]
+// messages (all of them if negative) and drops other causes, preserving
+// the order of msgs.
+func selectByPolicy(msgs []Message, policy map[CauseBy]int) []Message {
+	left := make(map[CauseBy]int, len(policy))
+	for cause, n := range policy {
+		left[cause] = n
+	}
+	keep := make([]bool, len(msgs))
+	kept := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		n, ok := left[msgs[i].CauseBy]
+		if !ok || n == 0 {
+			continue
+		}
+		left[msgs[i].CauseBy] = n - 1
+		keep[i] = true
+		kept++
+	}
+
+	out := make([]Message, 0, kept)
+	for i, msg := range msgs {
+		if keep[i] {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// latestByCause returns the newest n messages of msgs caused by causeBy,
+// oldest first; a negative n returns all of them.
+func latestByCause(msgs []Message, causeBy CauseBy, n int) []Message {
+	return selectByPolicy(msgs, map[CauseBy]int{causeBy: n})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// policyHistory returns three code messages, five reviews and two test
+// messages after a requirement, interleaved; each content names its cause
+// and number, e.g. "code 2".
+func policyHistory() []Message {
+	msgs := []Message{NewMessage(nil, "the requirement", "Human", CauseUserRequirement)}
+	counts := map[CauseBy]int{}
+	names := map[CauseBy]string{CauseWriteCode: "code", CauseWriteReview: "review", CauseWriteTest: "test"}
+	for _, cause := range []CauseBy{CauseWriteCode, CauseWriteReview, CauseWriteTest, CauseWriteReview, CauseWriteCode,
+		CauseWriteReview, CauseWriteReview, CauseWriteTest, CauseWriteCode, CauseWriteReview} {
+		counts[cause]++
+		msgs = append(msgs, NewMessage(nil, fmt.Sprintf("%s %d", names[cause], counts[cause]), "Someone", cause))
+	}
+	return msgs
+}
+
+func TestSelectByPolicy(t *testing.T) {
+	got := selectByPolicy(policyHistory(), map[CauseBy]int{CauseWriteCode: 1, CauseWriteReview: 3, CauseWriteTest: -1})
+	var contents []string
+	for _, msg := range got {
+		contents = append(contents, msg.Content)
+	}
+	want := []string{"test 1", "review 3", "review 4", "test 2", "code 3", "review 5"}
+	if !slices.Equal(contents, want) {
+		t.Errorf("got %v, want %v", contents, want)
+	}
+}
+
+func TestContextPolicyShapesPrompt(t *testing.T) {
+	p := newPipelineProvider()
+	r := &Role{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{}, Actions: []Action{&SimpleWriteReview{llmClient: p,
+		ActionOptions: ActionOptions{ContextPolicy: map[CauseBy]int{CauseWriteCode: 1, CauseWriteReview: 3}}}}}
+	for _, msg := range policyHistory() {
+		r.Memory.Add(msg)
+	}
+	if _, err := r.Act(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	prompt := p.prompts()[0]
+	for _, in := range []string{"code 3", "review 3", "review 4", "review 5"} {
+		if !strings.Contains(prompt, in) {
+			t.Errorf("prompt lacks %q", in)
+		}
+	}
+	for _, out := range []string{"code 2", "review 2", "test 1", "test 2", "the requirement"} {
+		if strings.Contains(prompt, out) {
+			t.Errorf("prompt has %q, outside the policy", out)
+		}
+	}
+}
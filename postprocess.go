@@ -0,0 +1,38 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PostProcessor transforms a raw model reply before it is parsed.
+type PostProcessor func(string) string
+
+func applyPostProcessors(content string, pps []PostProcessor) string {
+	for _, pp := range pps {
+		content = pp(content)
+	}
+	return content
+}
+
+// NormalizeLineEndings converts CRLF and lone CR line endings to LF.
+func NormalizeLineEndings(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
+
+// TrimTrailingSpace removes trailing whitespace from every line and from the
+// end of s.
+func TrimTrailingSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+var markdownBold = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+
+// StripMarkdownBold replaces **bold** spans with their plain text.
+func StripMarkdownBold(s string) string {
+	return markdownBold.ReplaceAllString(s, "$1")
+}
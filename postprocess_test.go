@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestPostProcessorPipeline(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+		return "  LGTM, Ship It  \n\n", nil
+	}}
+	opts := ActionOptions{PostProcessors: []PostProcessor{strings.TrimSpace, strings.ToLower}}
+	got, err := opts.chat(context.Background(), p, CauseWriteReview, "review this")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "lgtm, ship it"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPostProcessorsRunInOrder(t *testing.T) {
+	got := applyPostProcessors("ab", []PostProcessor{
+		func(s string) string { return s + "c" },
+		strings.ToUpper,
+	})
+	if got != "ABC" {
+		t.Errorf("got %q, want %q", got, "ABC")
+	}
+	if got := applyPostProcessors("x\r\ny  \r\n", []PostProcessor{NormalizeLineEndings, TrimTrailingSpace}); got != "x\ny" {
+		t.Errorf("built-ins: got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PRD is the product requirement document SimpleWritePRD produces.
+type PRD struct {
+	Title        string   `json:"title"`
+	Goals        []string `json:"goals"`
+	UserStories  []string `json:"user_stories"`
+	Requirements []string `json:"requirements"`
+}
+
+// prdSchema is shown to the model; Validate enforces the same shape.
+const prdSchema = `{
+  "title": string,
+  "goals": [string, ...],          // at least one
+  "user_stories": [string, ...],   // at least one, "As a ..., I want ..., so that ..."
+  "requirements": [string, ...]    // at least one
+}`
+
+// ErrInvalidPRD is returned (wrapped) when the model's PRD does not match
+// the schema.
+var ErrInvalidPRD = errors.New("invalid PRD")
+
+// Validate checks that every required PRD field is present and non-empty.
+func (p PRD) Validate() error {
+	var missing []string
+	if strings.TrimSpace(p.Title) == "" {
+		missing = append(missing, "title")
+	}
+	for _, field := range []struct {
+		name string
+		list []string
+	}{
+		{"goals", p.Goals},
+		{"user_stories", p.UserStories},
+		{"requirements", p.Requirements},
+	} {
+		name, list := field.name, field.list
+		if len(list) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+		for _, item := range list {
+			if strings.TrimSpace(item) == "" {
+				missing = append(missing, name+" (empty item)")
+				break
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidPRD, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func parsePRD(content string) (PRD, error) {
+	var prd PRD
+	if err := json.Unmarshal([]byte(parseCode(content)), &prd); err != nil {
+		return PRD{}, fmt.Errorf("%w: %v", ErrInvalidPRD, err)
+	}
+	return prd, prd.Validate()
+}
+
+// SimpleWritePRD turns the user requirement into a PRD in JSON form. A reply
+// that does not match the schema is retried once with the validation error.
+type SimpleWritePRD struct {
+	ActionOptions
+	llmClient LLMProvider
+}
+
+func (a *SimpleWritePRD) Name() CauseBy { return CauseWritePRD }
+
+func (a *SimpleWritePRD) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleWritePRD) Run(ctx context.Context, contextData string) (string, error) {
+	prd, err := a.WritePRD(ctx, contextData)
+	if err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(prd, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return a.label(a.Name(), string(out)), nil
+}
+
+// WritePRD asks the model for a PRD and returns it validated.
+func (a *SimpleWritePRD) WritePRD(ctx context.Context, contextData string) (PRD, error) {
+	prompt := fmt.Sprintf("Context: %s\nWrite a product requirement document for the requirement above.\nReturn a single JSON object matching this schema with NO other texts:\n%s", contextData, prdSchema)
+
+	for attempt := 0; ; attempt++ {
+		content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+		if err != nil {
+			return PRD{}, err
+		}
+		prd, err := parsePRD(content)
+		if err == nil || attempt >= 1 || !allowRetry(ctx) {
+			return prd, err
+		}
+		prompt = fmt.Sprintf("%s\nYour previous answer was rejected: %v", prompt, err)
+	}
+}
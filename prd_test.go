@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const validPRD = "```json\n" + `{"title": "Product", "goals": ["multiply lists"], "user_stories": ["As a user, I want a product, so that I save time"], "requirements": ["handle empty lists"]}` + "\n```"
+
+func TestWritePRD(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		replies []string
+		wantErr bool
+		calls   int
+	}{
+		{"valid", []string{validPRD}, false, 1},
+		{"invalid then valid", []string{`{"title": "Product"}`, validPRD}, false, 2},
+		{"not JSON twice", []string{"Sure! Here is a PRD.", "Still prose."}, true, 2},
+		{"empty item twice", []string{`{"title": "P", "goals": [""], "user_stories": ["s"], "requirements": ["r"]}`, `{"title": " "}`}, true, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			replies := tc.replies
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+				r := replies[0]
+				replies = replies[1:]
+				return r, nil
+			}}
+			prd, err := (&SimpleWritePRD{llmClient: p}).WritePRD(context.Background(), "product of a list")
+			if p.calls() != tc.calls {
+				t.Errorf("%d calls, want %d", p.calls(), tc.calls)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidPRD) {
+					t.Errorf("got %v, want ErrInvalidPRD", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prd.Title != "Product" || len(prd.UserStories) != 1 {
+				t.Errorf("got %+v", prd)
+			}
+			if tc.calls > 1 && !strings.Contains(p.prompts()[1], "missing goals") {
+				t.Errorf("retry prompt lacks the validation error: %q", p.prompts()[1])
+			}
+		})
+	}
+}
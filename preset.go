@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownPreset is returned (wrapped) by PresetRole for names it does not
+// know.
+var ErrUnknownPreset = errors.New("unknown preset role")
+
+var presetRoles = map[string]func(llm LLMProvider) *Role{
+	"coder": func(llm LLMProvider) *Role {
+		return &Role{Name: "Alice", Profile: "SimpleCoder", Actions: []Action{&SimpleWriteCode{llmClient: llm}}, WatchList: []CauseBy{CauseUserRequirement}}
+	},
+	"tester": func(llm LLMProvider) *Role {
+		return &Role{Name: "Bob", Profile: "SimpleTester", Actions: []Action{&SimpleWriteTest{llmClient: llm}}, WatchList: []CauseBy{CauseWriteCode}}
+	},
+	"reviewer": func(llm LLMProvider) *Role {
+		return &Role{Name: "Charlie", Profile: "SimpleReviewer", Actions: []Action{&SimpleWriteReview{llmClient: llm}}, WatchList: []CauseBy{CauseWriteTest}}
+	},
+	"pm": func(llm LLMProvider) *Role {
+		return &Role{Name: "Diana", Profile: "SimpleProductManager", Actions: []Action{&SimpleWritePRD{llmClient: llm}}, WatchList: []CauseBy{CauseUserRequirement}}
+	},
+	"architect": func(llm LLMProvider) *Role {
+		return &Role{Name: "Evan", Profile: "SimpleArchitect", Actions: []Action{&SimpleWriteSchema{llmClient: llm}}, WatchList: []CauseBy{CauseWritePRD}}
+	},
+	"writer": func(llm LLMProvider) *Role {
+		return &Role{Name: "Fiona", Profile: "SimpleTechWriter", Actions: []Action{&SimpleWriteReadme{llmClient: llm}}, WatchList: []CauseBy{CauseWritePRD, CauseWriteSchema, CauseWriteCode, CauseWriteTest, CauseWriteReview}}
+	},
+}
+
+// PresetRole returns a new role for one of the presets "Coder", "Tester",
+// "Reviewer", "PM", "Architect" and "Writer" (case-insensitive), wired to
+// llm and with an empty memory.
+func PresetRole(name string, llm LLMProvider) (*Role, error) {
+	preset, ok := presetRoles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (have %s)", ErrUnknownPreset, name, strings.Join(PresetNames(), ", "))
+	}
+	r := preset(llm)
+	r.Memory = &Memory{}
+	return r, nil
+}
+
+// PresetNames lists the names PresetRole accepts, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(presetRoles))
+	for name := range presetRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const sampleReadme = "# Product\n\n## Usage\n\n```python\nproduct([2, 3])\n```\n\n## Testing\n\nRun pytest."
+
+// studioReply answers every preset's prompt in the form its action
+// expects.
+func studioReply(req openai.ChatCompletionRequest) (string, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	switch {
+	case strings.Contains(prompt, "product requirement document"):
+		return validPRD, nil
+	case strings.Contains(prompt, "database schema"):
+		return "```sql\n" + sampleDDL + "\n```", nil
+	case strings.Contains(prompt, "README.md"):
+		return sampleReadme, nil
+	default:
+		return pipelineReply(req)
+	}
+}
+
+func TestPresetRoles(t *testing.T) {
+	llm := &mockProvider{reply: studioReply}
+	team := &Team{ProjectIdea: "an inventory of items", Output: io.Discard}
+	for _, name := range PresetNames() {
+		r, err := PresetRole(name, llm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Memory == nil || len(r.Actions) == 0 || len(r.WatchList) == 0 || r.Name == "" {
+			t.Errorf("preset %s is incomplete: %+v", name, r)
+		}
+		team.Roles = append(team.Roles, r)
+	}
+	msgs, err := team.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := causes(msgs)
+	for _, cause := range []CauseBy{CauseWritePRD, CauseWriteSchema, CauseWriteCode, CauseWriteTest, CauseWriteReview, CauseWriteReadme} {
+		if !slices.Contains(got, cause) {
+			t.Errorf("no %s among %v", cause, got)
+		}
+	}
+
+	if _, err := PresetRole("Astronaut", llm); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("got %v, want ErrUnknownPreset", err)
+	}
+	if r, err := PresetRole("CODER", llm); err != nil || r.Profile != "SimpleCoder" {
+		t.Errorf("case-insensitive lookup: %v, %v", r, err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoleState is reported through Team.OnRoleState as roles start and finish.
+type RoleState int
+
+const (
+	RoleRunning RoleState = iota
+	RoleDone
+	RoleFailed
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Progress draws a one-line status of the working roles on a terminal. The
+// transcript must be written through Wrap so that status redraws never land
+// in the middle of it.
+type Progress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	working map[*Role]bool
+	done    int
+	failed  int
+	frame   int
+	stop    chan struct{}
+}
+
+// NewProgress returns a Progress drawing on out, or nil if out is not a
+// terminal. All Progress methods are no-ops on a nil receiver.
+func NewProgress(out *os.File) *Progress {
+	if !isTerminal(out) {
+		return nil
+	}
+	p := &Progress{out: out, working: make(map[*Role]bool), stop: make(chan struct{})}
+	go p.tick()
+	return p
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Attach hooks the indicator into t's role state callbacks, keeping any
+// callback already set.
+func (p *Progress) Attach(t *Team) {
+	if p == nil {
+		return
+	}
+	prev := t.OnRoleState
+	t.OnRoleState = func(r *Role, state RoleState) {
+		p.update(r, state)
+		if prev != nil {
+			prev(r, state)
+		}
+	}
+}
+
+// Wrap returns a writer that clears the status line before writing to w and
+// redraws it afterwards.
+func (p *Progress) Wrap(w io.Writer) io.Writer {
+	if p == nil {
+		return w
+	}
+	return progressWriter{p: p, w: w}
+}
+
+// Stop clears the status line and stops the spinner.
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// update records r's state. Roles are tracked by identity, since several
+// roles can share a Profile.
+func (p *Progress) update(r *Role, state RoleState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch state {
+	case RoleRunning:
+		p.working[r] = true
+	case RoleDone:
+		delete(p.working, r)
+		p.done++
+	case RoleFailed:
+		delete(p.working, r)
+		p.failed++
+	}
+	p.draw()
+}
+
+func (p *Progress) tick() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// clear and draw must be called with p.mu held.
+func (p *Progress) clear() {
+	fmt.Fprint(p.out, "\r\033[K")
+}
+
+func (p *Progress) draw() {
+	p.clear()
+	if len(p.working) == 0 {
+		return
+	}
+	names := make([]string, 0, len(p.working))
+	for r := range p.working {
+		names = append(names, r.Profile)
+	}
+	sort.Strings(names)
+	status := fmt.Sprintf("%s working: %s (%d done", spinnerFrames[p.frame%len(spinnerFrames)], strings.Join(names, ", "), p.done)
+	if p.failed > 0 {
+		status += fmt.Sprintf(", %d failed", p.failed)
+	}
+	fmt.Fprint(p.out, status+")")
+}
+
+type progressWriter struct {
+	p *Progress
+	w io.Writer
+}
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	pw.p.mu.Lock()
+	defer pw.p.mu.Unlock()
+	pw.p.clear()
+	n, err := pw.w.Write(b)
+	pw.p.draw()
+	return n, err
+}
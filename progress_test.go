@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// newTestProgress returns a Progress drawing on out without a spinner
+// goroutine.
+func newTestProgress(out *bytes.Buffer) *Progress {
+	return &Progress{out: out, working: make(map[*Role]bool), stop: make(chan struct{})}
+}
+
+func TestProgressTracksRolesSharingAProfile(t *testing.T) {
+	var out bytes.Buffer
+	p := newTestProgress(&out)
+	a := &Role{Name: "Alice", Profile: "Engineer"}
+	b := &Role{Name: "Bob", Profile: "Engineer"}
+
+	p.update(a, RoleRunning)
+	p.update(b, RoleRunning)
+	p.update(a, RoleDone)
+	out.Reset()
+	p.draw()
+	if want := "\r\033[K| working: Engineer (1 done)"; out.String() != want {
+		t.Errorf("status %q, want %q", out.String(), want)
+	}
+
+	p.update(b, RoleFailed)
+	out.Reset()
+	p.draw()
+	if out.String() != "\r\033[K" {
+		t.Errorf("status %q after every role finished, want a cleared line", out.String())
+	}
+}
+
+func TestProgressWrapRedrawsAroundWrites(t *testing.T) {
+	// The transcript and the status line share one terminal.
+	var term bytes.Buffer
+	p := newTestProgress(&term)
+	p.update(&Role{Name: "Alice", Profile: "Engineer"}, RoleRunning)
+	term.Reset()
+
+	fmt.Fprint(p.Wrap(&term), "Engineer: done\n")
+	status := "| working: Engineer (0 done)"
+	if want := "\r\033[KEngineer: done\n\r\033[K" + status; term.String() != want {
+		t.Errorf("terminal got %q, want %q", term.String(), want)
+	}
+}
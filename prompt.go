@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+type promptFrame struct {
+	prefix, suffix string
+}
+
+type promptFrameKey struct{}
+
+// withPromptFrame makes every prompt sent under ctx start with prefix and
+// end with suffix. A frame already on ctx stays outermost, so a sub-team's
+// prefix follows its parent's and its suffix precedes the parent's.
+func withPromptFrame(ctx context.Context, prefix, suffix string) context.Context {
+	if prefix == "" && suffix == "" {
+		return ctx
+	}
+	outer, _ := ctx.Value(promptFrameKey{}).(promptFrame)
+	return context.WithValue(ctx, promptFrameKey{}, promptFrame{
+		prefix: joinNonEmpty(outer.prefix, prefix),
+		suffix: joinNonEmpty(suffix, outer.suffix),
+	})
+}
+
+// framePrompt wraps prompt in the frame carried by ctx, blank lines apart.
+func framePrompt(ctx context.Context, prompt string) string {
+	f, _ := ctx.Value(promptFrameKey{}).(promptFrame)
+	return joinNonEmpty(f.prefix, prompt, f.suffix)
+}
+
+func joinNonEmpty(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
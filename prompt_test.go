@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	safetyPrefix = "Do not include secrets or PII."
+	safetySuffix = "Answer in English."
+)
+
+func TestPromptFrame(t *testing.T) {
+	p := newPipelineProvider()
+	team := newPipelineTeam(t, p)
+	team.PromptPrefix, team.PromptSuffix = safetyPrefix, safetySuffix
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := p.prompts()
+	if len(prompts) != 3 {
+		t.Fatalf("%d requests, want 3", len(prompts))
+	}
+	for i, prompt := range prompts {
+		if !strings.HasPrefix(prompt, safetyPrefix+"\n\n") || !strings.HasSuffix(prompt, "\n\n"+safetySuffix) {
+			t.Errorf("request %d is not framed:\n%s", i, prompt)
+		}
+		if strings.Count(prompt, safetyPrefix) != 1 {
+			t.Errorf("request %d repeats the prefix:\n%s", i, prompt)
+		}
+	}
+	if !strings.Contains(prompts[0], "Write a python function") {
+		t.Errorf("the action's own prompt is lost:\n%s", prompts[0])
+	}
+
+	plain := newPipelineProvider()
+	if _, err := newPipelineTeam(t, plain).RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(plain.prompts()[0], safetyPrefix) {
+		t.Error("prefix sent without PromptPrefix")
+	}
+}
+
+func TestPromptFrameNestsSubTeams(t *testing.T) {
+	p := newPipelineProvider()
+	sub := newPipelineTeam(t, p)
+	sub.Roles = sub.Roles[:1]
+	sub.PromptPrefix, sub.PromptSuffix = "Sub prefix.", "Sub suffix."
+	parent := &Team{ProjectIdea: "write a function that returns the product of a list", Output: io.Discard,
+		PromptPrefix: safetyPrefix, PromptSuffix: safetySuffix, SubTeams: []*Team{sub}}
+	if _, err := parent.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	prompt := p.prompts()[0]
+	if !strings.HasPrefix(prompt, safetyPrefix+"\n\nSub prefix.\n\n") || !strings.HasSuffix(prompt, "\n\nSub suffix.\n\n"+safetySuffix) {
+		t.Errorf("frames not nested:\n%s", prompt)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// LLMProvider is the part of the OpenAI client the actions depend on.
+// *openai.Client satisfies it, and wrappers or mocks can stand in for it.
+type LLMProvider interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// ProviderNamer is implemented by providers that say which service they
+// talk to, e.g. "openai" or "ollama". The name prefixes the API errors the
+// actions return; providers without one are called "llm".
+type ProviderNamer interface {
+	Name() string
+}
+
+// Named wraps p so that it reports name, e.g. Named("ollama", client) for
+// a client pointed at a local endpoint.
+func Named(name string, p LLMProvider) LLMProvider {
+	return namedProvider{LLMProvider: p, name: name}
+}
+
+type namedProvider struct {
+	LLMProvider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+// providerName returns p's name, or "llm" if it has none.
+func providerName(p LLMProvider) string {
+	if n, ok := p.(ProviderNamer); ok && n.Name() != "" {
+		return n.Name()
+	}
+	return "llm"
+}
+
+type providerSettings struct {
+	orgID          string
+	project        string
+	httpClient     *http.Client
+	requestTimeout time.Duration
+}
+
+// ProviderOption customises the client built by NewOpenAIProvider.
+type ProviderOption func(*providerSettings)
+
+// WithOrganization sends the OpenAI-Organization header on every request.
+func WithOrganization(orgID string) ProviderOption {
+	return func(s *providerSettings) { s.orgID = orgID }
+}
+
+// WithProject sends the OpenAI-Project header on every request.
+func WithProject(project string) ProviderOption {
+	return func(s *providerSettings) { s.project = project }
+}
+
+// WithHTTPClient sends all requests through client, e.g. one with a proxy,
+// timeouts or custom root CAs. It replaces config.HTTPClient.
+func WithHTTPClient(client *http.Client) ProviderOption {
+	return func(s *providerSettings) { s.httpClient = client }
+}
+
+// WithRequestTimeout bounds every HTTP request, reading the reply's body
+// included, to d, independently of the caller's context. The context's
+// deadline still applies too, so whichever of the two is shorter ends the
+// request. Unlike a context timeout, which spans all retries of an action,
+// d applies afresh to each request.
+func WithRequestTimeout(d time.Duration) ProviderOption {
+	return func(s *providerSettings) { s.requestTimeout = d }
+}
+
+// NewOpenAIProvider builds an OpenAI (or Azure OpenAI) client from config
+// with opts applied.
+func NewOpenAIProvider(config openai.ClientConfig, opts ...ProviderOption) *openai.Client {
+	var s providerSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if s.httpClient != nil {
+		config.HTTPClient = s.httpClient
+	}
+	if s.orgID != "" {
+		config.OrgID = s.orgID
+	}
+	if s.project != "" {
+		next := config.HTTPClient
+		if next == nil {
+			next = http.DefaultClient
+		}
+		header := make(http.Header)
+		header.Set("OpenAI-Project", s.project)
+		config.HTTPClient = headerDoer{next: next, header: header}
+	}
+	if s.requestTimeout > 0 {
+		next := config.HTTPClient
+		if next == nil {
+			next = http.DefaultClient
+		}
+		config.HTTPClient = timeoutDoer{next: next, timeout: s.requestTimeout}
+	}
+	return openai.NewClientWithConfig(config)
+}
+
+// headerDoer adds fixed headers to every request before passing it on.
+type headerDoer struct {
+	next   openai.HTTPDoer
+	header http.Header
+}
+
+func (d headerDoer) Do(req *http.Request) (*http.Response, error) {
+	for k, v := range d.header {
+		req.Header[k] = v
+	}
+	return d.next.Do(req)
+}
+
+// timeoutDoer gives every request its own deadline, derived from the
+// request's context, and releases it once the reply's body is closed.
+type timeoutDoer struct {
+	next    openai.HTTPDoer
+	timeout time.Duration
+}
+
+func (d timeoutDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), d.timeout)
+	resp, err := d.next.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
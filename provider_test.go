@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// chatServer is an OpenAI-compatible endpoint answering every chat
+// completion with reply and recording the requests' headers.
+type chatServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func newChatServer(t *testing.T, reply string) *chatServer {
+	s := &chatServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			ID:      "chatcmpl-test",
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply}}},
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *chatServer) config() openai.ClientConfig {
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = s.URL + "/v1"
+	return config
+}
+
+func TestProviderOrganizationAndProject(t *testing.T) {
+	s := newChatServer(t, "ok")
+	client := NewOpenAIProvider(s.config(), WithOrganization("org-123"), WithProject("proj_abc"))
+	if _, err := (ActionOptions{}).chat(context.Background(), client, CauseWriteCode, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.headers) != 1 {
+		t.Fatalf("server saw %d requests", len(s.headers))
+	}
+	h := s.headers[0]
+	if h.Get("OpenAI-Organization") != "org-123" || h.Get("OpenAI-Project") != "proj_abc" {
+		t.Errorf("headers %v lack the organization and project", h)
+	}
+	if h.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("authorization header %q", h.Get("Authorization"))
+	}
+
+	plain := NewOpenAIProvider(s.config())
+	if _, err := (ActionOptions{}).chat(context.Background(), plain, CauseWriteCode, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if h := s.headers[1]; h.Get("OpenAI-Organization") != "" || h.Get("OpenAI-Project") != "" {
+		t.Errorf("unconfigured client sent %v", h)
+	}
+}
+
+// redirectTransport sends every request to target, whatever its URL says,
+// counting them.
+type redirectTransport struct {
+	target *url.URL
+	mu     sync.Mutex
+	n      int
+}
+
+func (rt *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.n++
+	rt.mu.Unlock()
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProviderHTTPClient(t *testing.T) {
+	s := newChatServer(t, "through the proxy")
+	target, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server is reachable only through the custom client.
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = "http://llm.internal.invalid/v1"
+
+	if _, err := (ActionOptions{}).chat(context.Background(), NewOpenAIProvider(config), CauseWriteCode, "hi"); err == nil {
+		t.Fatal("reached the server without the custom client")
+	}
+
+	rt := &redirectTransport{target: target}
+	client := NewOpenAIProvider(config, WithHTTPClient(&http.Client{Transport: rt}))
+	got, err := (ActionOptions{}).chat(context.Background(), client, CauseWriteCode, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "through the proxy" || rt.n != 1 || len(s.headers) != 1 {
+		t.Errorf("got %q with %d requests through the client, %d at the server", got, rt.n, len(s.headers))
+	}
+}
+
+// newSlowServer is an OpenAI-compatible endpoint that answers "late" after
+// delay, or gives up when the client or the test does.
+func newSlowServer(t *testing.T, delay time.Duration) openai.ClientConfig {
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		case <-done:
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "late"}}},
+		})
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(done) })
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = s.URL + "/v1"
+	return config
+}
+
+func TestRequestTimeoutVersusContextDeadline(t *testing.T) {
+	const short, long = 50 * time.Millisecond, 5 * time.Second
+	for _, tc := range []struct {
+		name              string
+		request, deadline time.Duration
+		ctxExpired        bool
+	}{
+		{"request timeout shorter", short, long, false},
+		{"context deadline shorter", long, short, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewOpenAIProvider(newSlowServer(t, time.Second), WithRequestTimeout(tc.request))
+			ctx, cancel := context.WithTimeout(context.Background(), tc.deadline)
+			defer cancel()
+
+			start := time.Now()
+			_, err := (ActionOptions{}).chat(ctx, client, CauseWriteCode, "hi")
+			elapsed := time.Since(start)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v, want a deadline error", err)
+			}
+			if elapsed > 500*time.Millisecond {
+				t.Errorf("request ran for %v; the shorter limit, %v, should have ended it", elapsed, short)
+			}
+			if expired := ctx.Err() != nil; expired != tc.ctxExpired {
+				t.Errorf("caller's context expired: %v, want %v", expired, tc.ctxExpired)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutAppliesPerRequest(t *testing.T) {
+	client := NewOpenAIProvider(newSlowServer(t, 80*time.Millisecond), WithRequestTimeout(300*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 5; i++ {
+		got, err := (ActionOptions{}).chat(ctx, client, CauseWriteCode, "hi")
+		if err != nil || got != "late" {
+			t.Fatalf("request %d: got %q, %v; the timeout should restart with each request", i, got, err)
+		}
+	}
+}
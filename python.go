@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PythonInterpreter is the interpreter ValidatePython shells out to.
+var PythonInterpreter = "python3"
+
+// ErrPythonSkipped is returned by ValidatePython when the configured
+// interpreter is not installed, so the check could not run.
+var ErrPythonSkipped = errors.New("python syntax check skipped: interpreter not found")
+
+// pythonCheckTimeout bounds a single ValidatePython run on top of ctx.
+const pythonCheckTimeout = 10 * time.Second
+
+// pythonCompile compiles the file named by its argument without writing
+// bytecode, unlike py_compile, which leaves a .pyc behind.
+const pythonCompile = "import sys; compile(open(sys.argv[1]).read(), sys.argv[1], 'exec')"
+
+// ValidatePython checks that code compiles. It returns nil if the code is
+// valid, ErrPythonSkipped if no interpreter is available, and an error
+// carrying the interpreter's message otherwise. The interpreter is killed
+// when ctx is done or after pythonCheckTimeout.
+func ValidatePython(ctx context.Context, code string) error {
+	interp, err := exec.LookPath(PythonInterpreter)
+	if err != nil {
+		return ErrPythonSkipped
+	}
+
+	f, err := os.CreateTemp("", "metagpt-*.py")
+	if err != nil {
+		return fmt.Errorf("python syntax check: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(code); err != nil {
+		f.Close()
+		return fmt.Errorf("python syntax check: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("python syntax check: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pythonCheckTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, interp, "-c", pythonCompile, f.Name()).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("python syntax check: %w", ctx.Err())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("python syntax error: %s", strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("python syntax check: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func requirePython(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath(PythonInterpreter); err != nil {
+		t.Skip("no python interpreter")
+	}
+}
+
+// fakeInterpreter points PythonInterpreter at a shell script with the
+// given body for the rest of the test.
+func fakeInterpreter(t *testing.T, body string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "python")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := PythonInterpreter
+	PythonInterpreter = path
+	t.Cleanup(func() { PythonInterpreter = old })
+}
+
+func TestValidatePython(t *testing.T) {
+	requirePython(t)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	if err := ValidatePython(context.Background(), sampleCode); err != nil {
+		t.Fatalf("valid code rejected: %v", err)
+	}
+	err := ValidatePython(context.Background(), "def product(lst)\n    return 1")
+	if err == nil || !strings.Contains(err.Error(), "SyntaxError") {
+		t.Fatalf("invalid code: got %v, want a SyntaxError", err)
+	}
+
+	left, err := filepath.Glob(filepath.Join(tmp, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("check left files behind: %v", left)
+	}
+}
+
+func TestValidatePythonSkipsWithoutInterpreter(t *testing.T) {
+	old := PythonInterpreter
+	PythonInterpreter = "no-such-python-interpreter"
+	defer func() { PythonInterpreter = old }()
+
+	if err := ValidatePython(context.Background(), sampleCode); !errors.Is(err, ErrPythonSkipped) {
+		t.Fatalf("got %v, want ErrPythonSkipped", err)
+	}
+}
+
+func TestValidatePythonHonoursContext(t *testing.T) {
+	fakeInterpreter(t, "exec sleep 30")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := ValidatePython(ctx, sampleCode)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want a deadline error", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("stuck interpreter held the check for %v", time.Since(start))
+	}
+}
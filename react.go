@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrLoopDetected is returned by React when the role keeps producing the
+// same output.
+var ErrLoopDetected = errors.New("react loop detected: outputs are repeating")
+
+const (
+	defaultLoopWindow     = 3
+	defaultLoopSimilarity = 0.95
+)
+
+// React lets the role act repeatedly, each step seeing its previous output
+// in memory, until ReactDone reports the last message as final or maxSteps
+// steps have run. If the last LoopWindow outputs are all at least
+// LoopSimilarity alike, it stops with ErrLoopDetected. The messages produced
+// are returned in either case.
+func (r *Role) React(ctx context.Context, maxSteps int) ([]Message, error) {
+	window := r.LoopWindow
+	if window <= 0 {
+		window = defaultLoopWindow
+	}
+	threshold := r.LoopSimilarity
+	if threshold <= 0 {
+		threshold = defaultLoopSimilarity
+	}
+
+	var out []Message
+	for step := 0; step < maxSteps; step++ {
+		if err := ctx.Err(); err != nil {
+			return out, err
+		}
+		msg, err := r.Act(ctx)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, msg)
+
+		if r.ReactDone != nil && r.ReactDone(msg) {
+			return out, nil
+		}
+		if isLooping(out, window, threshold, r.Similarity) {
+			return out, ErrLoopDetected
+		}
+	}
+	return out, nil
+}
+
+// isLooping reports whether the last window messages are all similar to
+// the newest one by sim, TokenSimilarity if nil.
+func isLooping(msgs []Message, window int, threshold float64, sim func(a, b string) float64) bool {
+	if window < 2 || len(msgs) < window {
+		return false
+	}
+	if sim == nil {
+		sim = TokenSimilarity
+	}
+	last := msgs[len(msgs)-1].Content
+	for _, m := range msgs[len(msgs)-window : len(msgs)-1] {
+		if sim(m.Content, last) < threshold {
+			return false
+		}
+	}
+	return true
+}
+
+// TokenSimilarity is the Jaccard overlap of the whitespace-separated tokens
+// of a and b: 1 for identical token sets, 0 for disjoint ones. It is the
+// default Similarity of roles and memories.
+func TokenSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ta, tb := tokenSet(a), tokenSet(b)
+	if len(ta) == 0 && len(tb) == 0 {
+		return 1
+	}
+	shared := 0
+	for tok := range ta {
+		if tb[tok] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func tokenSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, tok := range strings.Fields(s) {
+		set[tok] = true
+	}
+	return set
+}
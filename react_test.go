@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func reactRole(reply func(openai.ChatCompletionRequest) (string, error)) (*Role, *mockProvider) {
+	p := &mockProvider{reply: reply}
+	r := &Role{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteReview{llmClient: p}}}
+	r.Memory.Add(NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest))
+	return r, p
+}
+
+func TestReactDetectsLoop(t *testing.T) {
+	r, p := reactRole(func(openai.ChatCompletionRequest) (string, error) { return "Please add more tests.", nil })
+	msgs, err := r.React(context.Background(), 10)
+	if !errors.Is(err, ErrLoopDetected) {
+		t.Fatalf("got %v, want ErrLoopDetected", err)
+	}
+	if len(msgs) != defaultLoopWindow || p.calls() != defaultLoopWindow {
+		t.Errorf("stopped after %d messages and %d calls, want %d", len(msgs), p.calls(), defaultLoopWindow)
+	}
+}
+
+func TestReactWithoutLoop(t *testing.T) {
+	n := 0
+	r, _ := reactRole(func(openai.ChatCompletionRequest) (string, error) {
+		n++
+		return fmt.Sprintf("round %d: %s", n, []string{"naming", "edge cases", "docstrings", "typing", "performance"}[n%5]), nil
+	})
+	msgs, err := r.React(context.Background(), 5)
+	if err != nil || len(msgs) != 5 {
+		t.Fatalf("got %d messages, %v; want all 5 steps", len(msgs), err)
+	}
+
+	r, _ = reactRole(pipelineReply)
+	r.ReactDone = func(msg Message) bool { return true }
+	if msgs, err := r.React(context.Background(), 5); err != nil || len(msgs) != 1 {
+		t.Errorf("ReactDone: got %d messages, %v; want 1", len(msgs), err)
+	}
+}
+
+func TestTokenSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Please add more tests.", "Please add more tests.", 1, 1},
+		{"", "", 1, 1},
+		{"Please add more tests.", "Please  add more\ntests.", 1, 1},
+		{"Please add more tests for empty lists.", "Please add more tests for empty input.", 0.7, 0.99},
+		{"The code looks correct and well tested.", "The code looks correct and is well tested.", 0.85, 0.99},
+		{"Good job.", "Refactor the loop into a helper.", 0, 0},
+	} {
+		got := TokenSimilarity(tc.a, tc.b)
+		if got < tc.min || got > tc.max {
+			t.Errorf("TokenSimilarity(%q, %q) = %.2f, want within [%.2f, %.2f]", tc.a, tc.b, got, tc.min, tc.max)
+		}
+		if back := TokenSimilarity(tc.b, tc.a); back != got {
+			t.Errorf("not symmetric: %.2f and %.2f", got, back)
+		}
+	}
+}
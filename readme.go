@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SimpleWriteReadme writes the project README from the latest code, tests
+// and design (PRD and schema) in memory, so it always receives the whole
+// history. Its role watches the stages it documents and the last one, so
+// that it acts when the pipeline is done. The README's code fences are
+// normalised: untagged ones are tagged python, a fence left open is closed,
+// and a reply wrapped in a single markdown fence is unwrapped.
+type SimpleWriteReadme struct {
+	ActionOptions
+	llmClient LLMProvider
+}
+
+func (a *SimpleWriteReadme) Name() CauseBy { return CauseWriteReadme }
+
+func (a *SimpleWriteReadme) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleWriteReadme) ContextWindow() int { return -1 }
+
+func (a *SimpleWriteReadme) Run(ctx context.Context, contextData string) (string, error) {
+	return "", fmt.Errorf("%s needs the message history; run it through Role.Act", a.Name())
+}
+
+func (a *SimpleWriteReadme) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	code := latestContent(msgs, CauseWriteCode)
+	if code == "" {
+		return "", fmt.Errorf("%s: no code in memory yet", a.Name())
+	}
+
+	var b strings.Builder
+	for _, part := range []struct {
+		title string
+		cause CauseBy
+	}{
+		{"Requirement", CauseUserRequirement},
+		{"Product requirements", CauseWritePRD},
+		{"Schema", CauseWriteSchema},
+		{"Code", CauseWriteCode},
+		{"Tests", CauseWriteTest},
+	} {
+		if content := latestContent(msgs, part.cause); content != "" {
+			fmt.Fprintf(&b, "%s:\n%s\n\n", part.title, content)
+		}
+	}
+	prompt := fmt.Sprintf("%sWrite a README.md for this project in markdown with the sections Overview, Usage and Testing.\nRefer to the functions by name and put every code example in a ```python fence.\nReturn only the README with NO other texts.", truncateInput(b.String(), a.MaxInputBytes))
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return a.label(a.Name(), normalizeFences(strings.TrimSpace(content), "python")), nil
+}
+
+var markdownOpener = regexp.MustCompile("^```(?:markdown|md)[ \\t]*$")
+
+// normalizeFences tags untagged opening fences with lang, closes a fence
+// left open at the end and unwraps a document wrapped in one markdown
+// fence.
+func normalizeFences(md, lang string) string {
+	lines := strings.Split(md, "\n")
+	if markdownOpener.MatchString(strings.TrimSpace(lines[0])) {
+		lines = lines[1:]
+		// The wrapper's closing fence is the one that leaves the rest
+		// unbalanced.
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" && fenceCount(lines)%2 == 1 {
+			lines = lines[:n-1]
+		}
+	}
+
+	open := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		if !open && trimmed == "```" {
+			lines[i] = strings.Replace(line, "```", "```"+lang, 1)
+		}
+		open = !open
+	}
+	if open {
+		lines = append(lines, "```")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func fenceCount(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// readmeHistory is what the tech writer has seen once the pipeline ran.
+func readmeHistory() []Message {
+	return []Message{
+		NewMessage(nil, "write a function that returns the product of a list", "Human", CauseUserRequirement),
+		NewMessage(nil, sampleCode, "SimpleCoder", CauseWriteCode),
+		NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest),
+		NewMessage(nil, sampleReview, "SimpleReviewer", CauseWriteReview),
+	}
+}
+
+func TestWriteReadme(t *testing.T) {
+	const usage = "# Product\n\n## Usage\n\n"
+	for _, tc := range []struct {
+		name, reply, want string
+	}{
+		{"tagged", sampleReadme, sampleReadme},
+		{"untagged fence", usage + "```\nproduct([2, 3])\n```", usage + "```python\nproduct([2, 3])\n```"},
+		{"unclosed fence", usage + "```python\nproduct([2, 3])", usage + "```python\nproduct([2, 3])\n```"},
+		{"wrapped in markdown", "```markdown\n" + usage + "```python\nproduct([2, 3])\n```\n```", usage + "```python\nproduct([2, 3])\n```"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return tc.reply, nil }}
+			got, err := (&SimpleWriteReadme{llmClient: p}).RunMessages(context.Background(), readmeHistory())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+			for _, m := range pythonDef.FindAllStringSubmatch(sampleCode, -1) {
+				if !strings.Contains(got, m[1]) {
+					t.Errorf("README does not mention %s, defined by the code", m[1])
+				}
+			}
+			prompt := p.prompts()[0]
+			if !strings.Contains(prompt, sampleCode) || !strings.Contains(prompt, sampleTests) {
+				t.Errorf("prompt lacks the code or the tests:\n%s", prompt)
+			}
+		})
+	}
+}
+
+func TestWriteReadmeNeedsCode(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "# A project\n\nIt multiplies.", nil }}
+	a := &SimpleWriteReadme{llmClient: p}
+	if _, err := a.RunMessages(context.Background(), readmeHistory()[:1]); err == nil || p.calls() != 0 {
+		t.Errorf("no code in memory: got %v after %d calls", err, p.calls())
+	}
+	// The README is not checked against the code's definitions: helpers
+	// and private functions need not be documented.
+	if got, err := a.RunMessages(context.Background(), readmeHistory()); err != nil || got != "# A project\n\nIt multiplies." {
+		t.Errorf("README without the function: got %q, %v", got, err)
+	}
+}
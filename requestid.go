@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// metaRequestID is the Message.Meta key holding the request ID of the
+// action invocation that produced the message.
+const metaRequestID = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id as the request ID of the action
+// invocation it belongs to. Role.Act sets a fresh one for every action it
+// runs, shared by that action's retries.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// logf is log.Printf with the request ID of ctx, if any, in front.
+func logf(ctx context.Context, format string, args ...any) {
+	if id := RequestID(ctx); id != "" {
+		format = fmt.Sprintf("[request %s] %s", id, format)
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestRequestIDSharedAcrossRetries(t *testing.T) {
+	var ids []string
+	mock := newPipelineProvider()
+	llm := providerFunc(func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		ids = append(ids, RequestID(ctx))
+		if len(ids)%3 != 0 {
+			return openai.ChatCompletionResponse{}, errors.New("rate limited")
+		}
+		return mock.CreateChatCompletion(ctx, req)
+	})
+	r := &Role{Name: "Charlie", Profile: "SimpleReviewer", Memory: &Memory{},
+		Actions: []Action{&SimpleWriteReview{llmClient: llm, ActionOptions: ActionOptions{ErrorRetries: 2}}}}
+	r.Memory.Add(NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest))
+
+	var logged strings.Builder
+	old := log.Writer()
+	log.SetOutput(&logged)
+	defer log.SetOutput(old)
+
+	first, err := r.Act(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.Act(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 6 {
+		t.Fatalf("%d calls, want two actions of three attempts", len(ids))
+	}
+	if ids[0] == "" || ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Errorf("retries of one action carry %q, want one shared ID", ids[:3])
+	}
+	if ids[3] != ids[4] || ids[4] != ids[5] {
+		t.Errorf("retries of one action carry %q, want one shared ID", ids[3:])
+	}
+	if ids[0] == ids[3] {
+		t.Errorf("two actions share the request ID %q", ids[0])
+	}
+	if first.Meta[metaRequestID] != ids[0] || second.Meta[metaRequestID] != ids[3] {
+		t.Errorf("messages carry %q and %q, want %q and %q", first.Meta[metaRequestID], second.Meta[metaRequestID], ids[0], ids[3])
+	}
+	if n := strings.Count(logged.String(), "[request "+ids[0]+"]"); n != 2 {
+		t.Errorf("%d retry log lines name the request, want 2:\n%s", n, logged.String())
+	}
+}
+
+func TestRequestIDHelpers(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("empty context carries %q", id)
+	}
+	if id := RequestID(WithRequestID(context.Background(), "abc")); id != "abc" {
+		t.Errorf("got %q, want abc", id)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type retryBudgetKey struct{}
+
+// withRetryBudget attaches a shared pool of n retries to ctx, unless ctx
+// already carries one (sub-teams share their parent's).
+func withRetryBudget(ctx context.Context, n int) context.Context {
+	if n <= 0 || ctx.Value(retryBudgetKey{}) != nil {
+		return ctx
+	}
+	left := new(atomic.Int64)
+	left.Store(int64(n))
+	return context.WithValue(ctx, retryBudgetKey{}, left)
+}
+
+// allowRetry takes one retry from the run's budget and reports whether one
+// was left. Without a budget every retry is allowed.
+func allowRetry(ctx context.Context) bool {
+	left, ok := ctx.Value(retryBudgetKey{}).(*atomic.Int64)
+	if !ok {
+		return true
+	}
+	return left.Add(-1) >= 0
+}
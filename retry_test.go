@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestRetryBudgetIsShared(t *testing.T) {
+	ctx := withRetryBudget(context.Background(), 50)
+	var granted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if allowRetry(ctx) {
+					granted.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if granted.Load() != 50 {
+		t.Errorf("granted %d retries from a budget of 50", granted.Load())
+	}
+	if !allowRetry(context.Background()) {
+		t.Error("retry refused without a budget")
+	}
+}
+
+func TestRetryBudgetAcrossConcurrentRoles(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "", nil }}
+	team := &Team{ProjectIdea: "idea", Output: io.Discard, RetryBudget: 5}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		team.Roles = append(team.Roles, &Role{Name: name, Profile: name, Memory: &Memory{},
+			Actions:   []Action{&SimpleWriteReview{llmClient: p, ActionOptions: ActionOptions{EmptyRetries: 10}}},
+			WatchList: []CauseBy{CauseUserRequirement}})
+	}
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// One first attempt per role plus the five retries of the budget.
+	if p.calls() != 4+5 {
+		t.Errorf("%d provider calls, want 9", p.calls())
+	}
+}
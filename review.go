@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ReviewComment is one finding of a structured review.
+type ReviewComment struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// metaReviewComments is the Message.Meta key holding a review's comments as
+// JSON.
+const metaReviewComments = "review_comments"
+
+const structuredReviewInstructions = "List each comment on its own line as `SEVERITY: comment`, where SEVERITY is one of CRITICAL, MAJOR or MINOR."
+
+var reviewCommentLine = regexp.MustCompile(`(?im)^\s*(?:[-*]\s*)?\[?(critical|major|minor)\]?\s*[:\-]\s*(.+?)\s*$`)
+
+// ParseReviewComments extracts `SEVERITY: comment` lines from a review. If
+// none are found the whole text is returned as one comment of severity
+// "unknown".
+func ParseReviewComments(review string) []ReviewComment {
+	var out []ReviewComment
+	for _, m := range reviewCommentLine.FindAllStringSubmatch(review, -1) {
+		out = append(out, ReviewComment{Severity: strings.ToLower(m[1]), Message: m[2]})
+	}
+	if len(out) == 0 {
+		out = append(out, ReviewComment{Severity: "unknown", Message: strings.TrimSpace(review)})
+	}
+	return out
+}
+
+// ReviewComments returns the structured comments attached to a review
+// message, or nil if it has none.
+func ReviewComments(msg Message) []ReviewComment {
+	raw, ok := msg.Meta[metaReviewComments]
+	if !ok {
+		return nil
+	}
+	var out []ReviewComment
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil
+	}
+	return out
+}
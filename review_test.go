@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestParseReviewComments(t *testing.T) {
+	for _, tc := range []struct {
+		name, review string
+		want         []ReviewComment
+	}{
+		{
+			"well formed",
+			"Overall fine.\nCRITICAL: product([]) raises instead of returning 1\n- major: no test for negative numbers\n[MINOR] - the docstring is missing",
+			[]ReviewComment{
+				{Severity: "critical", Message: "product([]) raises instead of returning 1"},
+				{Severity: "major", Message: "no test for negative numbers"},
+				{Severity: "minor", Message: "the docstring is missing"},
+			},
+		},
+		{
+			"malformed",
+			"  The tests miss the empty list case.\nSeverity is high.  ",
+			[]ReviewComment{{Severity: "unknown", Message: "The tests miss the empty list case.\nSeverity is high."}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ParseReviewComments(tc.review); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReviewCommentsRoundTrip(t *testing.T) {
+	const review = "CRITICAL: product([]) raises\nMINOR: rename xs"
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return review, nil }}
+	r := &Role{Name: "Rex", Profile: "Reviewer", Memory: &Memory{}, Actions: []Action{&SimpleWriteReview{llmClient: p, StructuredComments: true}}}
+	r.Memory.Add(NewMessage(nil, sampleTests, "SimpleTester", CauseWriteTest))
+	msg, err := r.Act(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ReviewComments(msg), ParseReviewComments(review); !reflect.DeepEqual(got, want) {
+		t.Errorf("Meta %q decodes to %+v, want %+v", msg.Meta[metaReviewComments], got, want)
+	}
+
+	if ReviewComments(NewMessage(nil, review, "Rex", CauseWriteReview)) != nil {
+		t.Error("a message without the Meta key has comments")
+	}
+	bad := NewMessage(nil, review, "Rex", CauseWriteReview)
+	bad.Meta = map[string]string{metaReviewComments: "not json"}
+	if ReviewComments(bad) != nil {
+		t.Error("undecodable Meta yields comments")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultRollingWindow is how many recent rounds RollingSummary keeps
+// verbatim when RollingWindow is zero.
+const defaultRollingWindow = 2
+
+// rollSummaries runs at the start of every round when RollingSummary is
+// set. For each role with a Summarizer it folds the messages from before
+// the last RollingWindow rounds, including the previous rolling summary,
+// into a single summary, then marks where the new round starts.
+func (t *Team) rollSummaries(ctx context.Context) {
+	window := t.RollingWindow
+	if window <= 0 {
+		window = defaultRollingWindow
+	}
+
+	for _, r := range t.roles() {
+		t.mu.Lock()
+		marks := t.roundStarts[r]
+		t.mu.Unlock()
+
+		if r.Summarizer != nil && len(marks) >= window {
+			if cutoff := marks[len(marks)-window]; cutoff > 1 {
+				if err := r.summarizeOldest(ctx, cutoff); err != nil {
+					fmt.Fprintf(t.output(), "%s error: %v\n", r.Profile, err)
+				} else {
+					kept := append([]int(nil), marks[len(marks)-window:]...)
+					for i := range kept {
+						kept[i] -= cutoff - 1
+					}
+					marks = kept
+				}
+			}
+		}
+
+		t.mu.Lock()
+		if t.roundStarts == nil {
+			t.roundStarts = make(map[*Role][]int)
+		}
+		t.roundStarts[r] = append(marks, len(r.Memory.GetRecentN(-1)))
+		t.mu.Unlock()
+	}
+}
+
+// summarizeOldest replaces the oldest n messages of the role's memory with
+// a summary produced by its Summarizer.
+func (r *Role) summarizeOldest(ctx context.Context, n int) error {
+	history := r.Memory.GetRecentN(-1)
+	if n > len(history) {
+		n = len(history)
+	}
+	summary, err := r.Summarizer.Run(ctx, r.formatContext(history[:n]))
+	if err != nil {
+		return fmt.Errorf("%s summarization failed: %w", r.Summarizer.Name(), err)
+	}
+	r.Memory.Compact(n, newMessage(r.Clock, r.IDGenerator, summary, r.Profile, r.Summarizer.Name()))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// sizeAction records how many messages it is given on every run.
+type sizeAction struct {
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (a *sizeAction) Name() CauseBy { return "Chat" }
+
+func (a *sizeAction) ContextWindow() int { return -1 }
+
+func (a *sizeAction) Run(ctx context.Context, contextData string) (string, error) {
+	return "", fmt.Errorf("size: want RunMessages")
+}
+
+func (a *sizeAction) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sizes = append(a.sizes, len(msgs))
+	return fmt.Sprintf("reply %d", len(a.sizes)), nil
+}
+
+// chatTeam builds two roles that answer each other every round.
+func chatTeam(rolling bool) (*Team, *sizeAction) {
+	summarizer := &SimpleSummarize{llmClient: &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+		return "the conversation so far", nil
+	}}}
+	a, b := &sizeAction{}, &sizeAction{}
+	roles := []*Role{
+		{Name: "Ann", Profile: "First", Actions: []Action{a}, WatchList: []CauseBy{CauseUserRequirement, "Chat"}, Memory: &Memory{}, Summarizer: summarizer},
+		{Name: "Ben", Profile: "Second", Actions: []Action{b}, WatchList: []CauseBy{CauseUserRequirement, "Chat"}, Memory: &Memory{}, Summarizer: summarizer},
+	}
+	return &Team{Roles: roles, ProjectIdea: "talk", Output: io.Discard, RollingSummary: rolling}, a
+}
+
+func TestRollingSummaryStabilizesContext(t *testing.T) {
+	const rounds = 10
+
+	team, plain := chatTeam(false)
+	if _, err := team.RunProjectRounds(context.Background(), rounds); err != nil {
+		t.Fatal(err)
+	}
+	if len(plain.sizes) != rounds || plain.sizes[rounds-1] <= plain.sizes[rounds/2] {
+		t.Fatalf("without RollingSummary the context should keep growing: %v", plain.sizes)
+	}
+
+	team, a := chatTeam(true)
+	if _, err := team.RunProjectRounds(context.Background(), rounds); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.sizes) != rounds {
+		t.Fatalf("acted %d times, want %d", len(a.sizes), rounds)
+	}
+	settled := a.sizes[defaultRollingWindow+1]
+	for i, n := range a.sizes[defaultRollingWindow+1:] {
+		if n != settled {
+			t.Errorf("round %d saw %d messages, want a steady %d: %v", i+defaultRollingWindow+2, n, settled, a.sizes)
+		}
+	}
+	if settled >= plain.sizes[rounds-1] {
+		t.Errorf("context settled at %d messages, no smaller than the %d without RollingSummary", settled, plain.sizes[rounds-1])
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"slices"
+)
+
+// RunFailed retries the roles that produced nothing in a previous run,
+// given that run's messages. The messages are recorded and put into the
+// memories of the (fresh) roles as the run would have: the user
+// requirement for everyone, every other message for its author and its
+// watchers. Then only the roles without a message in prev act, in
+// dependency order. It returns what they produce.
+func (t *Team) RunFailed(ctx context.Context, prev []Message) ([]Message, error) {
+	roles := t.roles()
+	succeeded := make(map[*Role]bool)
+	for _, msg := range prev {
+		if r := authorOf(roles, msg); r != nil {
+			succeeded[r] = true
+		}
+	}
+
+	for _, r := range roles {
+		if r.IDGenerator == nil {
+			r.IDGenerator = t.IDGenerator
+		}
+	}
+	for _, msg := range prev {
+		t.record(msg)
+		if msg.CauseBy == CauseUserRequirement {
+			for _, r := range roles {
+				r.Memory.Add(msg)
+			}
+			continue
+		}
+		author := authorOf(roles, msg)
+		if author != nil {
+			author.Memory.Add(msg)
+		}
+		t.route(author, msg)
+	}
+
+	var failed []*Role
+	for _, r := range roles {
+		if !succeeded[r] {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	ctx, done := t.runScope(ctx)
+	defer done()
+
+	var produced []Message
+	for _, wave := range dependencyWaves(failed) {
+		if ctx.Err() != nil {
+			break
+		}
+		produced = append(produced, t.runWave(ctx, wave)...)
+	}
+	if ctx.Err() != nil {
+		return produced, context.Cause(ctx)
+	}
+	return produced, nil
+}
+
+// authorOf returns the role among roles that produced msg, or nil.
+func authorOf(roles []*Role, msg Message) *Role {
+	for _, r := range roles {
+		for _, a := range r.Actions {
+			if !slices.Contains(producedCauses(a), msg.CauseBy) {
+				continue
+			}
+			if msg.Role == r.Profile || (isTool(a) && ObservedBy(msg) == a.Name()) {
+				return r
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestRunFailedRerunsOnlyFailedRoles(t *testing.T) {
+	flaky := &mockProvider{reply: func(req openai.ChatCompletionRequest) (string, error) {
+		if strings.Contains(req.Messages[len(req.Messages)-1].Content, "Write a python function") {
+			return pipelineReply(req)
+		}
+		return "", errors.New("service unavailable")
+	}}
+	first := newPipelineTeam(t, flaky)
+	if _, err := first.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	prev := first.Transcript()
+	if got := causes(prev); !slices.Equal(got, []CauseBy{CauseUserRequirement, CauseWriteCode}) {
+		t.Fatalf("first run recorded %v, want the tester and reviewer to fail", got)
+	}
+
+	llm := newPipelineProvider()
+	retry := newPipelineTeam(t, llm)
+	produced, err := retry.RunFailed(context.Background(), prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := causes(produced); !slices.Equal(got, []CauseBy{CauseWriteTest, CauseWriteReview}) {
+		t.Errorf("retry produced %v, want the tests and the review", got)
+	}
+	prompts := llm.prompts()
+	if len(prompts) != 2 {
+		t.Fatalf("%d calls, want only the tester and the reviewer", len(prompts))
+	}
+	for _, p := range prompts {
+		if strings.Contains(p, "Write a python function") {
+			t.Errorf("the coder, which succeeded, was invoked again")
+		}
+	}
+	if !strings.Contains(prompts[0], sampleCode) || !strings.Contains(prompts[1], sampleTests) {
+		t.Errorf("retried roles did not build on the earlier output:\n%s", strings.Join(prompts, "\n---\n"))
+	}
+
+	again, err := newPipelineTeam(t, llm).RunFailed(context.Background(), append(prev, produced...))
+	if err != nil || len(again) != 0 || llm.calls() != 2 {
+		t.Errorf("complete run retried: %v, %v, %d calls", causes(again), err, llm.calls())
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// dependencyWaves groups roles into waves so that every role runs after the
+// roles producing the messages it watches. Roles within a wave are
+// independent and keep their relative slice order. Roles caught in a watch
+// cycle are placed together in a final wave.
+func dependencyWaves(roles []*Role) [][]*Role {
+	producers := make(map[CauseBy][]int)
+	for i, r := range roles {
+		for _, a := range r.Actions {
+			for _, cause := range producedCauses(a) {
+				producers[cause] = append(producers[cause], i)
+			}
+		}
+	}
+
+	deps := make([]map[int]bool, len(roles))
+	for i, r := range roles {
+		deps[i] = make(map[int]bool)
+		for _, watch := range r.WatchList {
+			for _, p := range producers[watch] {
+				if p != i {
+					deps[i][p] = true
+				}
+			}
+		}
+	}
+
+	done := make([]bool, len(roles))
+	remaining := len(roles)
+	var waves [][]*Role
+	for remaining > 0 {
+		var ready []int
+		for i := range roles {
+			if done[i] {
+				continue
+			}
+			blocked := false
+			for p := range deps[i] {
+				if !done[p] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				ready = append(ready, i)
+			}
+		}
+		if len(ready) == 0 {
+			for i := range roles {
+				if !done[i] {
+					ready = append(ready, i)
+				}
+			}
+		}
+
+		wave := make([]*Role, 0, len(ready))
+		for _, i := range ready {
+			done[i] = true
+			wave = append(wave, roles[i])
+		}
+		remaining -= len(ready)
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// byPriority returns msgs in delivery order: ascending Priority so the most
+// urgent is delivered last, ties broken by Timestamp, oldest first, then by
+// the Name of the producing role and by ID, so that the order does not
+// depend on which goroutine finished first. producers maps message IDs to
+// the roles that produced them; messages without one, such as merged
+// ones, go by their Role instead.
+func byPriority(msgs []Message, producers map[string]*Role) []Message {
+	name := func(msg Message) string {
+		if r := producers[msg.ID]; r != nil {
+			return r.Name
+		}
+		return msg.Role
+	}
+	out := append([]Message(nil), msgs...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		if an, bn := name(a), name(b); an != bn {
+			return an < bn
+		}
+		return compareIDs(a.ID, b.ID) < 0
+	})
+	return out
+}
+
+// byRolePriority returns roles ordered by descending Priority, keeping the
+// original order among equals.
+func byRolePriority(roles []*Role) []*Role {
+	out := append([]*Role(nil), roles...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Priority > out[j].Priority
+	})
+	return out
+}
+
+type concurrencyKey struct{}
+
+// withConcurrencyLimit lets at most n roles act at once under ctx, unless
+// ctx already carries a limit (sub-teams share their parent's). Zero means
+// no limit.
+func withConcurrencyLimit(ctx context.Context, n int) context.Context {
+	if n <= 0 || ctx.Value(concurrencyKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, concurrencyKey{}, make(chan struct{}, n))
+}
+
+// concurrencySlots returns the semaphore of ctx's limit, or nil.
+func concurrencySlots(ctx context.Context) chan struct{} {
+	slots, _ := ctx.Value(concurrencyKey{}).(chan struct{})
+	return slots
+}
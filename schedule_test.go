@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDependencyOrderIgnoresSliceOrder(t *testing.T) {
+	coder := &Role{Name: "Alice", Profile: "Coder", Actions: []Action{echoAction{CauseWriteCode}},
+		WatchList: []CauseBy{CauseUserRequirement}, Memory: &Memory{}}
+	tester := &Role{Name: "Bob", Profile: "Tester", Actions: []Action{echoAction{CauseWriteTest}},
+		WatchList: []CauseBy{CauseWriteCode}, Memory: &Memory{}}
+	reviewer := &Role{Name: "Charlie", Profile: "Reviewer", Actions: []Action{echoAction{CauseWriteReview}},
+		WatchList: []CauseBy{CauseWriteTest}, Memory: &Memory{}}
+	team := &Team{Roles: []*Role{tester, coder, reviewer}, ProjectIdea: "product of a list"}
+
+	var names []string
+	for _, wave := range dependencyWaves(team.Roles) {
+		for _, r := range wave {
+			names = append(names, r.Profile)
+		}
+	}
+	if want := []string{coder.Profile, tester.Profile, reviewer.Profile}; !slices.Equal(names, want) {
+		t.Errorf("waves run %v, want %v", names, want)
+	}
+
+	msgs, err := team.RunProjectRounds(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := causes(msgs), []CauseBy{CauseWriteCode, CauseWriteTest, CauseWriteReview}; !slices.Equal(got, want) {
+		t.Errorf("round produced %v, want %v", got, want)
+	}
+}
+
+func TestByPriorityDeliversUrgentLast(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		{ID: "urgent", Content: "fix the crash", CauseBy: CauseWriteReview, Priority: 2, Timestamp: at},
+		{ID: "late", Content: "rename a variable", CauseBy: CauseWriteReview, Timestamp: at.Add(time.Second)},
+		{ID: "early", Content: "add a docstring", CauseBy: CauseWriteReview, Timestamp: at},
+	}
+	var got []string
+	for _, msg := range byPriority(msgs, nil) {
+		got = append(got, msg.ID)
+	}
+	if want := []string{"early", "late", "urgent"}; !slices.Equal(got, want) {
+		t.Fatalf("delivery order %v, want %v", got, want)
+	}
+
+	watcher := &Role{Name: "Coder", Profile: "SimpleCoder", WatchList: []CauseBy{CauseWriteReview}, Memory: &Memory{}}
+	team := &Team{Roles: []*Role{watcher}}
+	for _, msg := range byPriority(msgs, nil) {
+		team.route(nil, msg)
+	}
+	if recent := watcher.Memory.GetRecentN(1); recent[0].ID != "urgent" {
+		t.Errorf("a default window sees %q, want the urgent message", recent[0].ID)
+	}
+}
+
+// logAction appends its role's name to a shared log when it runs.
+type logAction struct {
+	name string
+	mu   *sync.Mutex
+	log  *[]string
+}
+
+func (a logAction) Name() CauseBy { return "Log" }
+
+func (a logAction) Run(ctx context.Context, contextData string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	*a.log = append(*a.log, a.name)
+	return a.name, nil
+}
+
+func TestPriorityOrderUnderConcurrencyOne(t *testing.T) {
+	var mu sync.Mutex
+	var log []string
+	team := &Team{ProjectIdea: "idea", Output: io.Discard, MaxConcurrency: 1}
+	for _, r := range []struct {
+		name     string
+		priority int
+	}{{"low", 1}, {"high-a", 3}, {"mid", 2}, {"high-b", 3}, {"zero", 0}} {
+		team.Roles = append(team.Roles, &Role{Name: r.name, Profile: r.name, Priority: r.priority, Memory: &Memory{},
+			Actions: []Action{logAction{r.name, &mu, &log}}, WatchList: []CauseBy{CauseUserRequirement}})
+	}
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"high-a", "high-b", "mid", "low", "zero"}; !slices.Equal(log, want) {
+		t.Errorf("ran %v, want %v", log, want)
+	}
+}
+
+func TestByPriorityBreaksTimestampTies(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	amy, zed := &Role{Name: "Amy", Profile: "Zeta"}, &Role{Name: "Zed", Profile: "Alpha"}
+	msgs := []Message{
+		{ID: "msg-10", Role: "Alpha", Timestamp: at},
+		{ID: "msg-3", Role: "Zeta", Timestamp: at},
+		{ID: "msg-2", Role: "Alpha", Timestamp: at},
+	}
+	producers := map[string]*Role{"msg-10": zed, "msg-3": amy, "msg-2": zed}
+
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 0, 2}} {
+		in := []Message{msgs[order[0]], msgs[order[1]], msgs[order[2]]}
+		var got []string
+		for _, msg := range byPriority(in, producers) {
+			got = append(got, msg.ID)
+		}
+		if want := []string{"msg-3", "msg-2", "msg-10"}; !slices.Equal(got, want) {
+			t.Errorf("from %v: got %v, want %v", order, got, want)
+		}
+	}
+}
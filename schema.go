@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidSchema is returned (wrapped) when SimpleWriteSchema's output is
+// not a usable schema in the requested format.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// SimpleWriteSchema designs the data layer for the PRD in its context:
+// SQL DDL when Format is "sql" (the default) or an OpenAPI document when it
+// is "openapi". It is meant for a role watching "SimpleWritePRD"; output
+// that does not validate as the requested format is an error.
+type SimpleWriteSchema struct {
+	ActionOptions
+	llmClient LLMProvider
+	Format    string
+}
+
+func (a *SimpleWriteSchema) Name() CauseBy { return CauseWriteSchema }
+
+func (a *SimpleWriteSchema) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleWriteSchema) Run(ctx context.Context, contextData string) (string, error) {
+	var prompt string
+	var validate func(string) error
+	switch a.Format {
+	case "", "sql":
+		prompt = fmt.Sprintf("Context: %s\nDesign the relational database schema for the product above as SQL DDL: CREATE TABLE statements with primary keys, foreign keys and indexes.\nReturn ```sql your_ddl_here ``` with NO other texts.", contextData)
+		validate = ValidateSQL
+	case "openapi":
+		prompt = fmt.Sprintf("Context: %s\nDesign the HTTP API for the product above as an OpenAPI 3 document in YAML, with paths, request bodies, responses and component schemas.\nReturn ```yaml your_spec_here ``` with NO other texts.", contextData)
+		validate = validateOpenAPI
+	default:
+		return "", fmt.Errorf("unknown schema format %q", a.Format)
+	}
+
+	rsp, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return "", err
+	}
+	schema := parseCode(rsp)
+	if err := validate(schema); err != nil {
+		return "", err
+	}
+	return a.label(a.Name(), schema), nil
+}
+
+var sqlStatementStart = regexp.MustCompile(`(?i)^(CREATE|ALTER|DROP|INSERT|UPDATE|DELETE|COMMENT|GRANT|SET|BEGIN|COMMIT|PRAGMA|WITH|SELECT)\b`)
+
+// ValidateSQL checks that ddl is a list of statements separated by
+// semicolons, each starting with a known keyword and with balanced
+// parentheses. It is a sanity check, not a full SQL parser.
+func ValidateSQL(ddl string) error {
+	stmts, err := splitSQL(ddl)
+	if err != nil {
+		return err
+	}
+	if len(stmts) == 0 {
+		return fmt.Errorf("%w: no SQL statements", ErrInvalidSchema)
+	}
+	for i, stmt := range stmts {
+		if !sqlStatementStart.MatchString(stmt) {
+			first, _, _ := strings.Cut(stmt, "\n")
+			return fmt.Errorf("%w: statement %d does not look like SQL: %q", ErrInvalidSchema, i+1, first)
+		}
+	}
+	return nil
+}
+
+// splitSQL splits ddl at the semicolons outside quotes and comments,
+// dropping comments and empty statements.
+func splitSQL(ddl string) ([]string, error) {
+	var stmts []string
+	var cur strings.Builder
+	depth := 0
+	flush := func() error {
+		if depth != 0 {
+			return fmt.Errorf("%w: unbalanced parentheses in statement %d", ErrInvalidSchema, len(stmts)+1)
+		}
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		cur.Reset()
+		return nil
+	}
+
+	for i := 0; i < len(ddl); i++ {
+		c := ddl[i]
+		switch {
+		case c == '-' && strings.HasPrefix(ddl[i:], "--"):
+			end := strings.IndexByte(ddl[i:], '\n')
+			if end < 0 {
+				i = len(ddl)
+			} else {
+				i += end
+				cur.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(ddl[i:], "/*"):
+			end := strings.Index(ddl[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated comment", ErrInvalidSchema)
+			}
+			i += end + 3
+			cur.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(ddl[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidSchema)
+			}
+			cur.WriteString(ddl[i : i+end+2])
+			i += end + 1
+		case c == '(':
+			depth++
+			cur.WriteByte(c)
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced parentheses in statement %d", ErrInvalidSchema, len(stmts)+1)
+			}
+			cur.WriteByte(c)
+		case c == ';':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return stmts, nil
+}
+
+var (
+	openAPIVersion = regexp.MustCompile(`(?m)(^|[{,\s])["']?openapi["']?\s*:\s*["']?3\.`)
+	openAPIPaths   = regexp.MustCompile(`(?m)(^|[{,\s])["']?paths["']?\s*:`)
+)
+
+// validateOpenAPI checks that spec declares an OpenAPI 3 version and paths.
+func validateOpenAPI(spec string) error {
+	if !openAPIVersion.MatchString(spec) {
+		return fmt.Errorf("%w: missing openapi: 3.x version", ErrInvalidSchema)
+	}
+	if !openAPIPaths.MatchString(spec) {
+		return fmt.Errorf("%w: missing paths", ErrInvalidSchema)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const sampleDDL = `-- items; the name may hold ';'
+CREATE TABLE items (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT 'a;b'
+);
+/* lookups by name */
+CREATE INDEX items_name ON items (name);`
+
+const sampleOpenAPI = `openapi: 3.0.3
+info:
+  title: Items
+  version: "1"
+paths:
+  /items:
+    get:
+      responses:
+        "200":
+          description: all items`
+
+func TestValidateSQL(t *testing.T) {
+	if err := ValidateSQL(sampleDDL); err != nil {
+		t.Errorf("valid DDL rejected: %v", err)
+	}
+	for _, ddl := range []string{
+		"",
+		"-- only a comment",
+		"CREATE TABLE items (id INTEGER;",
+		"Here is your schema: a table of items.",
+		"CREATE TABLE t (name TEXT DEFAULT 'open);",
+		"CREATE TABLE t (id INT); /* unterminated",
+	} {
+		if err := ValidateSQL(ddl); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%q: got %v, want ErrInvalidSchema", ddl, err)
+		}
+	}
+}
+
+func TestSimpleWriteSchemaFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format, reply string
+		ok            bool
+	}{
+		{"", "```sql\n" + sampleDDL + "\n```", true},
+		{"sql", "I would create an items table.", false},
+		{"openapi", "```yaml\n" + sampleOpenAPI + "\n```", true},
+		{"openapi", "```yaml\nswagger: \"2.0\"\npaths: {}\n```", false},
+		{"graphql", "type Item { id: ID! }", false},
+	} {
+		p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return tc.reply, nil }}
+		got, err := (&SimpleWriteSchema{llmClient: p, Format: tc.format}).Run(context.Background(), validPRD)
+		if (err == nil) != tc.ok {
+			t.Errorf("format %q, reply %.30q: err = %v, want success %v", tc.format, tc.reply, err, tc.ok)
+			continue
+		}
+		if tc.ok && got != parseCode(tc.reply) {
+			t.Errorf("format %q: got %q", tc.format, got)
+		}
+	}
+}
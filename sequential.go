@@ -0,0 +1,31 @@
+package main
+
+import "context"
+
+// RunSequential seeds the project idea and runs one round in which the
+// roles take turns in the order of Roles, one at a time. Each role's
+// output is routed before the next role acts, so a role sees what the
+// roles before it produced in the same pass, and the order of the
+// transcript is fixed. Roles never act concurrently, which makes runs easy
+// to follow, e.g. for teaching; a role placed before its producers simply
+// sees their output only in a later run.
+func (t *Team) RunSequential(ctx context.Context) ([]Message, error) {
+	t.mu.Lock()
+	t.sequential = true
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.sequential = false
+		t.mu.Unlock()
+	}()
+	return t.RunProject(ctx)
+}
+
+// turns puts every role in a wave of its own, in order.
+func turns(roles []*Role) [][]*Role {
+	waves := make([][]*Role, len(roles))
+	for i, r := range roles {
+		waves[i] = []*Role{r}
+	}
+	return waves
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// turnAction replies with its name and the turns it has seen so far.
+type turnAction struct{ name string }
+
+func (a turnAction) Name() CauseBy { return "Turn" }
+
+func (a turnAction) ContextWindow() int { return -1 }
+
+func (a turnAction) Run(ctx context.Context, contextData string) (string, error) {
+	return "", errors.New("turn: want RunMessages")
+}
+
+func (a turnAction) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	var seen []string
+	for _, msg := range msgs {
+		if msg.CauseBy == a.Name() {
+			name, _, _ := strings.Cut(msg.Content, " ")
+			seen = append(seen, name)
+		}
+	}
+	return a.name + " after [" + strings.Join(seen, " ") + "]", nil
+}
+
+// turnTeam has roles that all watch each other, so the wave scheduler
+// would run them together.
+func turnTeam(names ...string) *Team {
+	team := &Team{ProjectIdea: "take turns", Output: io.Discard}
+	for _, name := range names {
+		team.Roles = append(team.Roles, &Role{Name: name, Profile: name, Memory: &Memory{},
+			Actions: []Action{turnAction{name}}, WatchList: []CauseBy{CauseUserRequirement, "Turn"}})
+	}
+	return team
+}
+
+func TestRunSequentialOrder(t *testing.T) {
+	want := []string{"A after []", "B after [A]", "C after [A B]", "D after [A B C]"}
+	for run := 0; run < 20; run++ {
+		msgs, err := turnTeam("A", "B", "C", "D").RunSequential(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, msg := range msgs {
+			got = append(got, msg.Content)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("run %d: got %q, want %q", run, got, want)
+		}
+	}
+
+	msgs, err := turnTeam("A", "B", "C", "D").RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range msgs {
+		if !strings.HasSuffix(msg.Content, " after []") {
+			t.Errorf("RunProject ran the roles in turn: %q", msg.Content)
+		}
+	}
+}
+
+func TestRunSequentialKeepsRosterOrder(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	slices.Reverse(team.Roles)
+	msgs, err := team.RunSequential(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := causes(msgs), []CauseBy{CauseWriteReview, CauseWriteTest, CauseWriteCode}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want the roster's order %v", got, want)
+	}
+}
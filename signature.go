@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrSignatureMismatch is returned (wrapped) when generated code does not
+// define SimpleWriteCode's RequiredSignature.
+var ErrSignatureMismatch = errors.New("required function not defined")
+
+var (
+	signatureSpec = regexp.MustCompile(`^(?:def\s+)?([A-Za-z_]\w*)\s*(?:\((.*)\))?\s*(?:->.*?)?:?$`)
+	pythonDef     = regexp.MustCompile(`(?ms)^[ \t]*(?:async[ \t]+)?def[ \t]+([A-Za-z_]\w*)[ \t]*\((.*?)\)[ \t]*(?:->[^:]*)?:`)
+)
+
+// checkSignature verifies that code defines the function described by sig:
+// "product", "product(lst)" or "def product(lst):". Parameters are compared
+// by name, ignoring annotations and defaults; a sig without parentheses only
+// requires the name.
+func checkSignature(code, sig string) error {
+	spec := signatureSpec.FindStringSubmatch(strings.TrimSpace(sig))
+	if spec == nil {
+		return fmt.Errorf("invalid required signature %q", sig)
+	}
+	name, params := spec[1], spec[2]
+	withParams := strings.Contains(sig, "(")
+
+	var found []string
+	for _, m := range pythonDef.FindAllStringSubmatch(code, -1) {
+		if m[1] != name {
+			continue
+		}
+		if !withParams || equalStrings(paramNames(m[2]), paramNames(params)) {
+			return nil
+		}
+		found = append(found, fmt.Sprintf("def %s(%s)", m[1], strings.Join(paramNames(m[2]), ", ")))
+	}
+	if len(found) > 0 {
+		return fmt.Errorf("%w: want %s, got %s", ErrSignatureMismatch, sig, strings.Join(found, "; "))
+	}
+	return fmt.Errorf("%w: no def %s", ErrSignatureMismatch, name)
+}
+
+// paramNames returns the parameter names of a Python parameter list.
+func paramNames(list string) []string {
+	var names []string
+	depth, start := 0, 0
+	split := func(end int) {
+		p := strings.TrimSpace(list[start:end])
+		if i := strings.IndexAny(p, ":="); i >= 0 {
+			p = strings.TrimSpace(p[:i])
+		}
+		if p != "" {
+			names = append(names, p)
+		}
+	}
+	for i, c := range list {
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				split(i)
+				start = i + 1
+			}
+		}
+	}
+	split(len(list))
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
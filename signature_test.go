@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestCheckSignature(t *testing.T) {
+	code := "import math\n\ndef product(lst: list[int], start: int = 1) -> int:\n    return math.prod(lst, start=start)\n"
+	for sig, ok := range map[string]bool{
+		"product":                         true,
+		"product(lst, start)":             true,
+		"def product(lst, start):":        true,
+		"def product(lst, start) -> int:": true,
+		"product(lst)":                    false,
+		"def total(lst):":                 false,
+	} {
+		err := checkSignature(code, sig)
+		if (err == nil) != ok {
+			t.Errorf("%q: got %v", sig, err)
+		}
+		if err != nil && !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("%q: got %v, want ErrSignatureMismatch", sig, err)
+		}
+	}
+	if err := checkSignature(code, "not a signature!"); err == nil || errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("invalid spec: got %v", err)
+	}
+}
+
+func TestRequiredSignature(t *testing.T) {
+	const wrong = "```python\ndef multiply(numbers):\n    return 1\n```"
+	right := "```python\n" + sampleCode + "\n```"
+	for _, tc := range []struct {
+		name    string
+		replies []string
+		calls   int
+		wantErr bool
+	}{
+		{"match", []string{right}, 1, false},
+		{"retry on mismatch", []string{wrong, right}, 2, false},
+		{"mismatch twice", []string{wrong, wrong}, 2, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			replies := tc.replies
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) {
+				r := replies[0]
+				replies = replies[1:]
+				return r, nil
+			}}
+			a := &SimpleWriteCode{llmClient: p, RequiredSignature: "def product(lst):"}
+			code, err := a.Run(context.Background(), "return the product of a list")
+			if p.calls() != tc.calls {
+				t.Errorf("%d calls, want %d", p.calls(), tc.calls)
+			}
+			if !strings.Contains(p.prompts()[0], "The code must define `def product(lst):`") {
+				t.Errorf("prompt does not state the signature:\n%s", p.prompts()[0])
+			}
+			if tc.calls > 1 && !strings.Contains(p.prompts()[1], "did not define the required function") {
+				t.Errorf("retry does not explain the mismatch:\n%s", p.prompts()[1])
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrSignatureMismatch) {
+					t.Errorf("got %v, want ErrSignatureMismatch", err)
+				}
+				return
+			}
+			if err != nil || !strings.Contains(code, "def product(lst):") {
+				t.Errorf("got %q, %v", code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import "log"
+
+// ConversationStats describes how much history a team's roles hold.
+type ConversationStats struct {
+	// TotalMessages and EstimatedTokens add up all role memories;
+	// MaxRoleHistory is the longest single memory, in messages.
+	TotalMessages   int
+	MaxRoleHistory  int
+	EstimatedTokens int
+}
+
+// ConversationStats measures the roles' memories as they are now.
+func (t *Team) ConversationStats() ConversationStats {
+	var st ConversationStats
+	for _, r := range t.roles() {
+		history := r.Memory.GetRecentN(-1)
+		st.TotalMessages += len(history)
+		st.MaxRoleHistory = max(st.MaxRoleHistory, len(history))
+		st.EstimatedTokens += estimateTokens(r.formatContext(history))
+	}
+	return st
+}
+
+// warnLongConversations logs every role whose history has grown past
+// ContextWarnFraction of its model's context window.
+func (t *Team) warnLongConversations() {
+	if t.ContextWarnFraction <= 0 {
+		return
+	}
+	for _, r := range t.roles() {
+		window := 0
+		for _, a := range r.Actions {
+			if m, ok := a.(ModelNamer); ok {
+				window = max(window, ModelContextWindow(m.ModelName()))
+			}
+		}
+		if window == 0 {
+			continue
+		}
+		tokens := estimateTokens(r.formatContext(r.Memory.GetRecentN(-1)))
+		if float64(tokens) > t.ContextWarnFraction*float64(window) {
+			log.Printf("%s: conversation is about %d tokens, %.0f%% of the %d-token context window; consider AutoSummarizeAt or RollingSummary", r.Profile, tokens, 100*float64(tokens)/float64(window), window)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestConversationStats(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	if st := team.ConversationStats(); st != (ConversationStats{}) {
+		t.Errorf("empty team: %+v", st)
+	}
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var want ConversationStats
+	for _, r := range team.Roles {
+		history := r.Memory.GetRecentN(-1)
+		want.TotalMessages += len(history)
+		want.MaxRoleHistory = max(want.MaxRoleHistory, len(history))
+		want.EstimatedTokens += estimateTokens(formatContext(history))
+	}
+	got := team.ConversationStats()
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	// Everyone holds the requirement; the tester and reviewer also get
+	// what they watch, and every role keeps its own output.
+	if got.TotalMessages != 8 || got.MaxRoleHistory != 3 {
+		t.Errorf("got %d messages, longest history %d; want 8 and 3", got.TotalMessages, got.MaxRoleHistory)
+	}
+	if got.EstimatedTokens < estimateTokens(sampleCode+sampleTests+sampleReview) {
+		t.Errorf("estimated %d tokens, less than the outputs alone", got.EstimatedTokens)
+	}
+}
+
+func TestContextWarnFraction(t *testing.T) {
+	for _, tc := range []struct {
+		fraction float64
+		warns    bool
+	}{
+		{0, false},
+		{0.9, false},
+		{0.001, true},
+	} {
+		var logged strings.Builder
+		old := log.Writer()
+		log.SetOutput(&logged)
+		team := newPipelineTeam(t, newPipelineProvider())
+		team.ContextWarnFraction = tc.fraction
+		_, err := team.RunProject(context.Background())
+		log.SetOutput(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		warned := strings.Contains(logged.String(), "of the 8192-token context window")
+		if warned != tc.warns {
+			t.Errorf("fraction %v: warned %v, want %v:\n%s", tc.fraction, warned, tc.warns, logged.String())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// runSubTeams runs every sub-team concurrently and returns their messages,
+// grouped by sub-team in SubTeams order. The outputs are added to the
+// parent's transcript and routed to its roles.
+func (t *Team) runSubTeams(ctx context.Context, rounds int) ([]Message, error) {
+	results := make([][]Message, len(t.SubTeams))
+	errs := make([]error, len(t.SubTeams))
+
+	var wg sync.WaitGroup
+	for i, sub := range t.SubTeams {
+		if sub.ProjectIdea == "" {
+			sub.ProjectIdea = t.ProjectIdea
+		}
+		wg.Add(1)
+		go func(i int, sub *Team) {
+			defer wg.Done()
+			results[i], errs[i] = sub.run(ctx, rounds)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("sub-team %d: %w", i, errs[i])
+			}
+		}(i, sub)
+	}
+	wg.Wait()
+
+	var all []Message
+	for _, msgs := range results {
+		all = append(all, msgs...)
+	}
+	t.record(all...)
+	for _, msg := range all {
+		t.route(nil, msg)
+	}
+	return all, errors.Join(errs...)
+}
+
+// integrate hands the integrator every message of the run and lets it act
+// once.
+func (t *Team) integrate(ctx context.Context, msgs []Message) (Message, error) {
+	r := t.Integrator
+	if r.Memory == nil {
+		r.Memory = &Memory{}
+	}
+	for _, msg := range msgs {
+		r.Memory.Add(msg)
+	}
+
+	msg, err := r.Act(ctx)
+	if err != nil {
+		return Message{}, fmt.Errorf("integrator %s: %w", r.Profile, err)
+	}
+	t.print(msg)
+	t.record(msg)
+	return msg, nil
+}
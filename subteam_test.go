@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// peakGauge tracks how many actions run at once.
+type peakGauge struct {
+	mu         sync.Mutex
+	running    int
+	peak       int
+	ranForTeam map[string]int
+}
+
+// peakAction holds a gauge slot for a moment and replies with its team.
+type peakAction struct {
+	team  string
+	gauge *peakGauge
+}
+
+func (a peakAction) Name() CauseBy { return "Peak" }
+
+func (a peakAction) Run(ctx context.Context, contextData string) (string, error) {
+	g := a.gauge
+	g.mu.Lock()
+	g.running++
+	g.peak = max(g.peak, g.running)
+	g.ranForTeam[a.team]++
+	g.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	g.mu.Lock()
+	g.running--
+	g.mu.Unlock()
+	return a.team, nil
+}
+
+func peakTeam(name string, g *peakGauge, roles int) *Team {
+	team := &Team{Output: io.Discard}
+	for i := 0; i < roles; i++ {
+		team.Roles = append(team.Roles, &Role{Name: name, Profile: name, Memory: &Memory{},
+			Actions: []Action{peakAction{name, g}}, WatchList: []CauseBy{CauseUserRequirement}})
+	}
+	return team
+}
+
+func TestSubTeamsShareConcurrencyLimit(t *testing.T) {
+	g := &peakGauge{ranForTeam: map[string]int{}}
+	parent := &Team{ProjectIdea: "idea", Output: io.Discard, MaxConcurrency: 2,
+		SubTeams: []*Team{peakTeam("frontend", g, 3), peakTeam("backend", g, 3)}}
+
+	msgs, err := parent.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.peak > 2 {
+		t.Errorf("%d actions ran at once, limit 2", g.peak)
+	}
+	got := map[string]int{}
+	for _, msg := range msgs {
+		got[msg.Content]++
+	}
+	if got["frontend"] != 3 || got["backend"] != 3 {
+		t.Errorf("aggregate holds %v, want 3 messages from each sub-team", got)
+	}
+	if n := len(parent.Transcript()); n < 6 {
+		t.Errorf("parent transcript has %d messages, want the sub-teams' 6", n)
+	}
+}
+
+func TestConcurrencyLimitOnEveryEntryPoint(t *testing.T) {
+	for name, run := range map[string]func(*Team) error{
+		"RunProject": func(team *Team) error { _, err := team.RunProject(context.Background()); return err },
+		"Resume": func(team *Team) error {
+			if err := team.seedIdea(); err != nil {
+				return err
+			}
+			_, err := team.Resume(context.Background(), 1)
+			return err
+		},
+		"RunFailed": func(team *Team) error {
+			_, err := team.RunFailed(context.Background(), []Message{NewMessage(nil, "idea", "User", CauseUserRequirement)})
+			return err
+		},
+	} {
+		g := &peakGauge{ranForTeam: map[string]int{}}
+		team := peakTeam("solo", g, 4)
+		team.ProjectIdea, team.MaxConcurrency = "idea", 1
+		if err := run(team); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if g.ranForTeam["solo"] != 4 {
+			t.Errorf("%s: %d roles ran, want 4", name, g.ranForTeam["solo"])
+		}
+		if g.peak != 1 {
+			t.Errorf("%s: %d actions ran at once, limit 1", name, g.peak)
+		}
+	}
+}
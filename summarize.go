@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// SimpleSummarize condenses a conversation into a short summary that can
+// stand in for it in later prompts.
+type SimpleSummarize struct {
+	ActionOptions
+	llmClient LLMProvider
+}
+
+func (a *SimpleSummarize) Name() CauseBy { return CauseSummarize }
+
+func (a *SimpleSummarize) provider() LLMProvider { return a.llmClient }
+
+func (a *SimpleSummarize) Run(ctx context.Context, contextData string) (string, error) {
+	prompt := fmt.Sprintf("Context: %s\nSummarize the conversation above. Keep the requirements, the latest code and tests, and any open review comments; drop everything else.", contextData)
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return "", err
+	}
+	return a.label(a.Name(), content), nil
+}
+
+// estimateTokens gives a rough token count for s, using the usual
+// four-characters-per-token rule of thumb.
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// summarize replaces the role's memory with a summary produced by its
+// Summarizer. Messages added while the summary is being written are kept.
+func (r *Role) summarize(ctx context.Context) error {
+	history := r.Memory.GetRecentN(-1)
+	if len(history) == 0 {
+		return nil
+	}
+
+	summary, err := r.Summarizer.Run(ctx, r.formatContext(history))
+	if err != nil {
+		return fmt.Errorf("%s summarization failed: %w", r.Summarizer.Name(), err)
+	}
+
+	r.Memory.Compact(len(history), newMessage(r.Clock, r.IDGenerator, summary, r.Profile, r.Summarizer.Name()))
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestAutoSummarize(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		threshold int
+		summaries int
+	}{
+		{"oversized memory", 500, 1},
+		{"small memory", 1 << 20, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "summary of the work", nil }}
+			a := &captureAction{window: -1}
+			r := &Role{Name: "Cap", Profile: "Capturer", Actions: []Action{a}, Memory: &Memory{},
+				AutoSummarizeAt: tc.threshold, Summarizer: &SimpleSummarize{llmClient: p}}
+			for i := 0; i < 20; i++ {
+				r.Memory.Add(Message{Content: strings.Repeat("long requirement text ", 10), Role: "Human", CauseBy: CauseUserRequirement})
+			}
+			if _, err := r.Act(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if p.calls() != tc.summaries {
+				t.Fatalf("summarizer called %d times, want %d", p.calls(), tc.summaries)
+			}
+			if tc.summaries == 0 {
+				if n := strings.Count(a.seen, "\n"); n != 20 {
+					t.Errorf("action saw %d messages, want all 20", n)
+				}
+				return
+			}
+			if a.seen != "[Capturer]: summary of the work\n" {
+				t.Errorf("action saw %q, want just the summary", a.seen)
+			}
+			if !strings.Contains(p.prompts()[0], "long requirement text") {
+				t.Errorf("summarizer was not given the history")
+			}
+		})
+	}
+}
+
+func TestAutoSummarizeRespectsContext(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "summary", nil }}
+	r := &Role{Name: "Cap", Profile: "Capturer", Actions: []Action{&captureAction{window: -1}}, Memory: &Memory{},
+		AutoSummarizeAt: 1, Summarizer: &SimpleSummarize{llmClient: p}}
+	r.Memory.Add(Message{Content: "a requirement long enough to summarize", Role: "Human", CauseBy: CauseUserRequirement})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Act(ctx); err == nil {
+		t.Fatal("Act succeeded under a cancelled context")
+	}
+	if got := r.Memory.GetRecentN(-1); len(got) != 1 || got[0].CauseBy != CauseUserRequirement {
+		t.Errorf("memory changed to %v", causes(got))
+	}
+}
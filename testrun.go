@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RunTests executes the latest generated tests against the latest generated
+// code with pytest. It is a tool: its messages are observations by "TestRun"
+// and start with the pytest exit code, followed by pytest's output. It only
+// runs when Team.AllowExecution is set.
+type RunTests struct{}
+
+func (a *RunTests) Name() CauseBy { return CauseTestRun }
+
+func (a *RunTests) IsTool() bool { return true }
+
+func (a *RunTests) ContextWindow() int { return -1 }
+
+func (a *RunTests) Run(ctx context.Context, contextData string) (string, error) {
+	return "", fmt.Errorf("%s needs the message history; run it through Role.Act", a.Name())
+}
+
+func (a *RunTests) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	code, tests := latestContent(msgs, CauseWriteCode), latestContent(msgs, CauseWriteTest)
+	if code == "" || tests == "" {
+		return "", fmt.Errorf("%s: no code or tests in memory yet", a.Name())
+	}
+
+	if err := checkExecution(ctx); err != nil {
+		return "", fmt.Errorf("%s: %w", a.Name(), err)
+	}
+	out, exitCode, err := runPytest(ctx, code, tests, "-q")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("exit code: %d\n%s", exitCode, strings.TrimSpace(out)), nil
+}
+
+// runPytest writes code to solution.py and tests, importing it, to
+// test_solution.py in a scratch directory and runs pytest there with args.
+// A failing test run is not an error; it is reported through exitCode.
+func runPytest(ctx context.Context, code, tests string, args ...string) (out string, exitCode int, err error) {
+	interp, err := exec.LookPath(PythonInterpreter)
+	if err != nil {
+		return "", 0, ErrPythonSkipped
+	}
+
+	dir, err := os.MkdirTemp("", "metagpt-pytest-")
+	if err != nil {
+		return "", 0, fmt.Errorf("pytest: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"solution.py":      code,
+		"test_solution.py": "from solution import *\n\n" + tests,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return "", 0, fmt.Errorf("pytest: %w", err)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, interp, append([]string{"-m", "pytest"}, append(args, "test_solution.py")...)...)
+	cmd.Dir = dir
+	raw, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(raw), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return string(raw), 0, fmt.Errorf("pytest: %w", err)
+	}
+	return string(raw), 0, nil
+}
+
+var (
+	testRunExit    = regexp.MustCompile(`(?m)^exit code: (\d+)`)
+	pytestPassed   = regexp.MustCompile(`\b(\d+) passed\b`)
+	pytestProblems = regexp.MustCompile(`\b\d+ (failed|errors?)\b`)
+)
+
+// PytestPassed reports whether a TestRun observation shows a green run: a zero
+// exit code, when present, and a pytest summary with passing tests and no
+// failures or errors.
+func PytestPassed(content string) bool {
+	if m := testRunExit.FindStringSubmatch(content); m != nil {
+		if code, _ := strconv.Atoi(m[1]); code != 0 {
+			return false
+		}
+	}
+	return pytestPassed.MatchString(content) && !pytestProblems.MatchString(content)
+}
+
+// StopWhenTestsPass returns a Team.EarlyStop condition matching TestRun
+// observations that passed reports as successful. A nil passed uses
+// PytestPassed.
+func StopWhenTestsPass(passed func(content string) bool) func(Message) bool {
+	if passed == nil {
+		passed = PytestPassed
+	}
+	return func(msg Message) bool {
+		return ObservedBy(msg) == CauseTestRun && passed(msg.Content)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPytestPassed(t *testing.T) {
+	for content, want := range map[string]bool{
+		"exit code: 0\n...\n3 passed in 0.02s":                    true,
+		"3 passed in 0.02s":                                       true,
+		"exit code: 1\n.F.\n1 failed, 2 passed in 0.03s":          false,
+		"exit code: 0\n1 failed, 2 passed in 0.03s":               false,
+		"exit code: 2\n1 error in 0.10s":                          false,
+		"exit code: 5\nno tests ran in 0.01s":                     false,
+		"exit code: 0\n2 passed, 1 warning in 0.02s":              true,
+		"exit code: 1\nERROR test_solution.py\n2 errors in 0.05s": false,
+	} {
+		if got := PytestPassed(content); got != want {
+			t.Errorf("PytestPassed(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
+
+func TestStopWhenTestsPass(t *testing.T) {
+	fakeInterpreter(t, "echo '...'; echo '3 passed in 0.02s'")
+	llm := newPipelineProvider()
+	team := newPipelineTeam(t, llm)
+	team.Roles[2].WatchList = []CauseBy{ObservationCause}
+	team.AllowExecution = true
+	team.AddRole(&Role{Name: "Runner", Profile: "TestRunner", Actions: []Action{&RunTests{}}, WatchList: []CauseBy{CauseWriteCode, CauseWriteTest}})
+	team.EarlyStop = StopWhenTestsPass(nil)
+
+	msgs, err := team.RunProjectRounds(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := causes(msgs), []CauseBy{CauseWriteCode, CauseWriteTest, ObservationCause}; !slices.Equal(got, want) {
+		t.Errorf("run produced %v, want it to stop after the passing test run %v", got, want)
+	}
+	if team.Round() != 1 {
+		t.Errorf("ran %d rounds, want 1", team.Round())
+	}
+}
+
+func TestStopWhenTestsPassCustomDetector(t *testing.T) {
+	obs := Message{Content: "exit code: 0\nALL GREEN", CauseBy: ObservationCause, Meta: map[string]string{metaTool: string(CauseTestRun)}}
+	if StopWhenTestsPass(nil)(obs) {
+		t.Error("PytestPassed accepted a report without a pytest summary")
+	}
+	stop := StopWhenTestsPass(func(content string) bool { return strings.Contains(content, "ALL GREEN") })
+	if !stop(obs) {
+		t.Error("custom detector ignored")
+	}
+	if stop(Message{Content: "ALL GREEN", CauseBy: CauseWriteReview}) {
+		t.Error("matched a message that is not a test run")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Tracer starts spans for a run. It has the shape of OpenTelemetry's
+// trace.Tracer reduced to what the team needs; OpenTelemetry adapts one.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced operation.
+type Span interface {
+	SetAttribute(key string, value any)
+	End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, any) {}
+func (noopSpan) End()                     {}
+
+type tracerKey struct{}
+
+// withTracer makes tr the tracer for the spans started under ctx. A nil tr
+// keeps whatever tracer ctx already carries.
+func withTracer(ctx context.Context, tr Tracer) context.Context {
+	if tr == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerKey{}, tr)
+}
+
+// startSpan starts a child span of the one in ctx. Without a tracer it
+// returns ctx unchanged and a span that does nothing.
+func startSpan(ctx context.Context, name string) (context.Context, Span) {
+	tr, ok := ctx.Value(tracerKey{}).(Tracer)
+	if !ok {
+		return ctx, noopSpan{}
+	}
+	return tr.Start(ctx, name)
+}
+
+// createChatCompletion is one traced provider call; the span records the
+// model and the token usage reported for it, which is also added to the
+// message being produced.
+func createChatCompletion(ctx context.Context, client LLMProvider, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, span := startSpan(ctx, "llm.chat")
+	defer span.End()
+	span.SetAttribute("llm.model", req.Model)
+	if id := RequestID(ctx); id != "" {
+		span.SetAttribute("request.id", id)
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		span.SetAttribute("error", err.Error())
+		return resp, err
+	}
+	span.SetAttribute("llm.prompt_tokens", resp.Usage.PromptTokens)
+	span.SetAttribute("llm.completion_tokens", resp.Usage.CompletionTokens)
+	span.SetAttribute("llm.total_tokens", resp.Usage.TotalTokens)
+	recordUsage(ctx, req.Model, resp.Usage)
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SimpleTranslate translates the prose in its context into TargetLang.
+// Fenced code blocks are swapped for placeholders before the text reaches
+// the model and restored verbatim afterwards. A translator role watches the
+// prose stages, such as CauseWriteReadme or CauseWritePRD.
+type SimpleTranslate struct {
+	ActionOptions
+	llmClient  LLMProvider
+	TargetLang string
+}
+
+func (a *SimpleTranslate) Name() CauseBy { return CauseTranslate }
+
+func (a *SimpleTranslate) provider() LLMProvider { return a.llmClient }
+
+// anyFence matches a whole fenced block. Four-backtick fences come first so
+// that a ``` fence nested inside one stays part of it.
+var anyFence = regexp.MustCompile("(?s)````.*?````|```.*?```|~~~.*?~~~")
+
+func (a *SimpleTranslate) Run(ctx context.Context, contextData string) (string, error) {
+	var blocks []string
+	prose := anyFence.ReplaceAllStringFunc(contextData, func(block string) string {
+		blocks = append(blocks, block)
+		return codePlaceholder(len(blocks) - 1)
+	})
+
+	prompt := fmt.Sprintf("Translate the following text into %s. Keep every placeholder of the form @@CODE<n>@@ exactly as it is and in place. Return only the translation with NO other texts.\n\n%s", a.TargetLang, prose)
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return "", err
+	}
+
+	for i, block := range blocks {
+		ph := codePlaceholder(i)
+		if !strings.Contains(content, ph) {
+			return "", fmt.Errorf("translation lost code block %d", i)
+		}
+		content = strings.Replace(content, ph, block, 1)
+	}
+	return a.label(a.Name(), content), nil
+}
+
+func codePlaceholder(i int) string {
+	return fmt.Sprintf("@@CODE%d@@", i)
+}
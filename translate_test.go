@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// upperTranslator "translates" the text after the instructions by
+// upper-casing it, which would visibly mangle any code it was given.
+func upperTranslator(req openai.ChatCompletionRequest) (string, error) {
+	_, text, _ := strings.Cut(req.Messages[0].Content, "\n\n")
+	return strings.ToUpper(text), nil
+}
+
+func TestTranslateKeepsCode(t *testing.T) {
+	p := &mockProvider{reply: upperTranslator}
+	input := "Call product with a list:\n```python\n" + sampleCode + "\n```\nand read the result.\n~~~\nproduct([2, 3])\n~~~"
+	got, err := (&SimpleTranslate{llmClient: p, TargetLang: "Shouting"}).Run(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CALL PRODUCT WITH A LIST:\n```python\n" + sampleCode + "\n```\nAND READ THE RESULT.\n~~~\nproduct([2, 3])\n~~~"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if prompt := p.prompts()[0]; strings.Contains(prompt, "def product") {
+		t.Errorf("code reached the model:\n%s", prompt)
+	}
+}
+
+func TestTranslateKeepsNestedFences(t *testing.T) {
+	p := &mockProvider{reply: upperTranslator}
+	block := "````markdown\nRun:\n```python\nproduct([2, 3])\n```\n````"
+	got, err := (&SimpleTranslate{llmClient: p, TargetLang: "Shouting"}).Run(context.Background(), "An example README:\n"+block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "AN EXAMPLE README:\n" + block; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTranslateLostPlaceholder(t *testing.T) {
+	p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return "Rufen Sie product auf.", nil }}
+	_, err := (&SimpleTranslate{llmClient: p, TargetLang: "German"}).Run(context.Background(), "Call:\n```python\nproduct([1])\n```")
+	if err == nil || !strings.Contains(err.Error(), "lost code block 0") {
+		t.Errorf("got %v, want a lost-block error", err)
+	}
+}
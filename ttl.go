@@ -0,0 +1,51 @@
+package main
+
+// expired reports whether msg is older than the memory's TTL.
+func (m *Memory) expired(msg Message) bool {
+	if m.TTL <= 0 || msg.Timestamp.IsZero() {
+		return false
+	}
+	return orRealClock(m.Clock).Now().Sub(msg.Timestamp) > m.TTL
+}
+
+// live returns the history without expired messages, sharing the backing
+// array when nothing has expired. Callers hold m.mu.
+func (m *Memory) live() []Message {
+	if m.TTL <= 0 {
+		return m.history
+	}
+	for i, msg := range m.history {
+		if m.expired(msg) {
+			out := append([]Message(nil), m.history[:i]...)
+			for _, msg := range m.history[i+1:] {
+				if !m.expired(msg) {
+					out = append(out, msg)
+				}
+			}
+			return out
+		}
+	}
+	return m.history
+}
+
+// All returns a copy of the whole unexpired history.
+func (m *Memory) All() []Message {
+	return m.GetRecentN(-1)
+}
+
+// Prune removes the expired messages from the history and returns how many
+// it removed.
+func (m *Memory) Prune() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pruneLocked()
+}
+
+func (m *Memory) pruneLocked() int {
+	kept := m.live()
+	removed := len(m.history) - len(kept)
+	if removed > 0 {
+		m.history = kept
+	}
+	return removed
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryTTL(t *testing.T) {
+	clock := newFakeClock()
+	m := &Memory{TTL: time.Hour, Clock: clock}
+	m.Add(NewMessage(clock, "old", "Human", CauseUserRequirement))
+	clock.Advance(40 * time.Minute)
+	m.Add(NewMessage(clock, "middle", "SimpleCoder", CauseWriteCode))
+	m.Add(Message{Content: "timeless", Role: "SimpleCoder", CauseBy: CauseWriteCode})
+	clock.Advance(40 * time.Minute)
+	m.Add(NewMessage(clock, "new", "SimpleTester", CauseWriteTest))
+
+	contents := func(msgs []Message) []string {
+		var out []string
+		for _, msg := range msgs {
+			out = append(out, msg.Content)
+		}
+		return out
+	}
+	if got, want := contents(m.All()), []string{"middle", "timeless", "new"}; !slices.Equal(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+	if got, want := contents(m.GetRecentN(10)), []string{"middle", "timeless", "new"}; !slices.Equal(got, want) {
+		t.Errorf("GetRecentN(10) = %v, want %v", got, want)
+	}
+	if got := contents(m.GetRecentN(2)); !slices.Equal(got, []string{"timeless", "new"}) {
+		t.Errorf("GetRecentN(2) = %v", got)
+	}
+	if len(m.history) != 4 {
+		t.Fatalf("reads removed messages: %d left", len(m.history))
+	}
+
+	if n := m.Prune(); n != 1 || len(m.history) != 3 {
+		t.Errorf("Prune removed %d, %d left; want 1 and 3", n, len(m.history))
+	}
+	clock.Advance(time.Hour + time.Minute)
+	if got := contents(m.All()); !slices.Equal(got, []string{"timeless"}) {
+		t.Errorf("after two hours: %v", got)
+	}
+	if n := m.Prune(); n != 2 || len(m.history) != 1 {
+		t.Errorf("Prune removed %d, %d left; want 2 and 1", n, len(m.history))
+	}
+	if n := m.Prune(); n != 0 {
+		t.Errorf("second Prune removed %d", n)
+	}
+}
+
+func TestMemoryWithoutTTLKeepsEverything(t *testing.T) {
+	clock := newFakeClock()
+	m := &Memory{Clock: clock}
+	m.Add(NewMessage(clock, "old", "Human", CauseUserRequirement))
+	clock.Advance(1000 * time.Hour)
+	if len(m.All()) != 1 || m.Prune() != 0 {
+		t.Error("messages expired without a TTL")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedAction is an Action whose input and output are Go values: Call
+// marshals the input into the prompt as JSON and unmarshals the model's
+// JSON reply into O. Outputs with a Validate() error method are validated.
+// As a plain Action it takes the context text as input and returns the
+// reply re-encoded as JSON.
+type TypedAction[I, O any] struct {
+	ActionOptions
+	llmClient LLMProvider
+	name      CauseBy
+	// Instruction tells the model what to do with the input.
+	Instruction string
+}
+
+// NewTypedAction returns a TypedAction called name that follows
+// instruction.
+func NewTypedAction[I, O any](name CauseBy, llm LLMProvider, instruction string) *TypedAction[I, O] {
+	return &TypedAction[I, O]{llmClient: llm, name: name, Instruction: instruction}
+}
+
+func (a *TypedAction[I, O]) Name() CauseBy { return a.name }
+
+func (a *TypedAction[I, O]) provider() LLMProvider { return a.llmClient }
+
+func (a *TypedAction[I, O]) Run(ctx context.Context, contextData string) (string, error) {
+	out, err := a.invoke(ctx, contextData)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return a.label(a.Name(), string(data)), nil
+}
+
+// Call runs the action on in.
+func (a *TypedAction[I, O]) Call(ctx context.Context, in I) (O, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		var zero O
+		return zero, fmt.Errorf("%s: encoding input: %w", a.Name(), err)
+	}
+	return a.invoke(ctx, string(data))
+}
+
+func (a *TypedAction[I, O]) invoke(ctx context.Context, input string) (O, error) {
+	var out O
+	shape, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return out, fmt.Errorf("%s: encoding output shape: %w", a.Name(), err)
+	}
+	prompt := fmt.Sprintf("Input: %s\n%s\nReturn a single JSON value shaped like this example with NO other texts:\n%s", input, a.Instruction, shape)
+
+	content, err := a.chat(ctx, a.llmClient, a.Name(), prompt)
+	if err != nil {
+		return out, err
+	}
+	if err := json.Unmarshal([]byte(parseCode(content)), &out); err != nil {
+		return out, fmt.Errorf("%s: decoding reply: %w", a.Name(), err)
+	}
+	if v, ok := any(out).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// FeatureRequest is the input of NewTypedPRDWriter.
+type FeatureRequest struct {
+	Feature     string   `json:"feature"`
+	Constraints []string `json:"constraints,omitempty"`
+}
+
+// NewTypedPRDWriter is an example TypedAction: it turns a FeatureRequest
+// into a validated PRD without any hand-written JSON handling.
+func NewTypedPRDWriter(llm LLMProvider) *TypedAction[FeatureRequest, PRD] {
+	return NewTypedAction[FeatureRequest, PRD]("TypedWritePRD", llm, "Write a product requirement document for the feature above, respecting its constraints.")
+}
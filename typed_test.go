@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestTypedActionRoundTrip(t *testing.T) {
+	in := FeatureRequest{Feature: "product of a list", Constraints: []string{"no imports", "handle []"}}
+	var sent FeatureRequest
+	p := &mockProvider{reply: func(req openai.ChatCompletionRequest) (string, error) {
+		prompt := req.Messages[len(req.Messages)-1].Content
+		line, _, _ := strings.Cut(strings.TrimPrefix(prompt, "Input: "), "\n")
+		if err := json.Unmarshal([]byte(line), &sent); err != nil {
+			t.Errorf("input is not JSON in the prompt: %v\n%s", err, prompt)
+		}
+		if !strings.Contains(prompt, `"user_stories"`) {
+			t.Errorf("prompt does not show the output shape:\n%s", prompt)
+		}
+		return validPRD, nil
+	}}
+
+	prd, err := NewTypedPRDWriter(p).Call(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sent, in) {
+		t.Errorf("model was sent %+v, want %+v", sent, in)
+	}
+	want := PRD{Title: "Product", Goals: []string{"multiply lists"}, UserStories: []string{"As a user, I want a product, so that I save time"}, Requirements: []string{"handle empty lists"}}
+	if !reflect.DeepEqual(prd, want) {
+		t.Errorf("got %+v, want %+v", prd, want)
+	}
+
+	plain := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return validPRD, nil }}
+	out, err := NewTypedPRDWriter(plain).Run(context.Background(), "product of a list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded PRD
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil || !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Run returned %q (%v), want the PRD as JSON", out, err)
+	}
+}
+
+func TestTypedActionRejectsBadReplies(t *testing.T) {
+	for _, tc := range []struct {
+		name, reply string
+		want        error
+	}{
+		{"not JSON", "Here is your PRD!", nil},
+		{"invalid PRD", `{"title": "Product"}`, ErrInvalidPRD},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &mockProvider{reply: func(openai.ChatCompletionRequest) (string, error) { return tc.reply, nil }}
+			_, err := NewTypedPRDWriter(p).Call(context.Background(), FeatureRequest{Feature: "x"})
+			if err == nil {
+				t.Fatal("bad reply accepted")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
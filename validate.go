@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrValidation is returned (wrapped, with the failures joined) when a
+// finished run fails one of Team.Validators.
+var ErrValidation = errors.New("run failed validation")
+
+// validate runs the team's validators on msgs.
+func (t *Team) validate(msgs []Message) error {
+	var errs []error
+	for _, v := range t.Validators {
+		if err := v(msgs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrValidation, errors.Join(errs...))
+}
+
+// DefinesFunction returns a validator requiring the latest generated code
+// to define the function described by sig, as in
+// SimpleWriteCode.RequiredSignature.
+func DefinesFunction(sig string) func([]Message) error {
+	return func(msgs []Message) error {
+		code := latestContent(msgs, CauseWriteCode)
+		if code == "" {
+			return fmt.Errorf("no %s output", CauseWriteCode)
+		}
+		return checkSignature(code, sig)
+	}
+}
+
+// TestsUsePytest is a validator requiring the latest generated tests to be
+// pytest tests: at least one test_ function using assert.
+func TestsUsePytest(msgs []Message) error {
+	tests := latestContent(msgs, CauseWriteTest)
+	if tests == "" {
+		return fmt.Errorf("no %s output", CauseWriteTest)
+	}
+	for _, m := range pythonDef.FindAllStringSubmatch(tests, -1) {
+		if strings.HasPrefix(m[1], "test") && strings.Contains(tests, "assert") {
+			return nil
+		}
+	}
+	return errors.New("tests define no pytest test functions with asserts")
+}
+
+// ReviewNotEmpty is a validator requiring a non-blank review.
+func ReviewNotEmpty(msgs []Message) error {
+	if strings.TrimSpace(latestContent(msgs, CauseWriteReview)) == "" {
+		return fmt.Errorf("no %s output", CauseWriteReview)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func pipelineValidators() []func([]Message) error {
+	return []func([]Message) error{DefinesFunction("def product(lst):"), TestsUsePytest, ReviewNotEmpty}
+}
+
+func TestValidatorsPass(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.Validators = pipelineValidators()
+	if _, err := team.RunProject(context.Background()); err != nil {
+		t.Errorf("valid run failed validation: %v", err)
+	}
+}
+
+func TestValidatorsRejectMissingCode(t *testing.T) {
+	// The coder's only call fails, so the run has no code.
+	team := newPipelineTeam(t, &ChaosProvider{Provider: newPipelineProvider(), FailureFunc: FailCalls(1)})
+	team.Validators = pipelineValidators()
+	msgs, err := team.RunProject(context.Background())
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("got %v, want ErrValidation", err)
+	}
+	if !strings.Contains(err.Error(), "no SimpleWriteCode output") {
+		t.Errorf("error does not name the missing code: %v", err)
+	}
+	if len(msgs) == 0 {
+		t.Error("messages dropped on a validation failure")
+	}
+}
+
+func TestValidatorsReportEveryFailure(t *testing.T) {
+	team := &Team{Validators: pipelineValidators()}
+	msgs := []Message{
+		NewMessage(nil, "def total(xs):\n    return sum(xs)", "SimpleCoder", CauseWriteCode),
+		NewMessage(nil, "def check():\n    print('ok')", "SimpleTester", CauseWriteTest),
+		NewMessage(nil, "  ", "SimpleReviewer", CauseWriteReview),
+	}
+	err := team.validate(msgs)
+	if !errors.Is(err, ErrValidation) || !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("got %v", err)
+	}
+	for _, want := range []string{"no def product", "no pytest test functions", "no SimpleWriteReview output"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q: %v", want, err)
+		}
+	}
+	if err := (&Team{}).validate(msgs); err != nil {
+		t.Errorf("no validators: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	verdictLine  = regexp.MustCompile(`(?im)^\s*verdict:\s*(approve|reject)\b`)
+	approveWords = regexp.MustCompile(`(?i)\b(approved?|lgtm)\b`)
+	rejectWords  = regexp.MustCompile(`(?i)\b(reject(ed)?|request(ing)? changes)\b`)
+)
+
+// VoteAggregator turns the verdicts of several independent reviewers into a
+// single "Approved" or "Rejected" message. As a role's action it reads every
+// review in the role's memory and produces the decision with CauseApproved
+// or CauseRejected, so downstream roles can watch either outcome:
+//
+//	&Role{Name: "Lead", Profile: "Lead", Actions: []Action{&VoteAggregator{}},
+//		WatchList: []CauseBy{CauseWriteReview}}
+type VoteAggregator struct {
+	// Threshold is the number of approvals needed; zero means a strict
+	// majority of the reviews.
+	Threshold int
+	// ReviewCause selects the review messages; empty means
+	// "SimpleWriteReview".
+	ReviewCause CauseBy
+	// Verdict reports whether a review approves. Nil uses ParseVerdict,
+	// treating reviews without a clear verdict as not approving.
+	Verdict func(review Message) bool
+	Clock   Clock
+}
+
+// ParseVerdict reads an approve/reject verdict from a review, preferring an
+// explicit "VERDICT: APPROVE|REJECT" line. ok is false if the review does not
+// clearly say either.
+func ParseVerdict(content string) (approve, ok bool) {
+	if m := verdictLine.FindStringSubmatch(content); m != nil {
+		return strings.EqualFold(m[1], "approve"), true
+	}
+	a, r := approveWords.MatchString(content), rejectWords.MatchString(content)
+	if a == r {
+		return false, false
+	}
+	return a, true
+}
+
+func (v *VoteAggregator) Name() CauseBy { return "VoteAggregator" }
+
+// Causes lists the two decisions the aggregator produces.
+func (v *VoteAggregator) Causes() []CauseBy { return []CauseBy{CauseApproved, CauseRejected} }
+
+func (v *VoteAggregator) ContextWindow() int { return -1 }
+
+func (v *VoteAggregator) Run(ctx context.Context, contextData string) (string, error) {
+	return "", fmt.Errorf("%s needs the message history; run it through Role.Act", v.Name())
+}
+
+// RunMessages aggregates the reviews among msgs and marks the produced
+// message with the decision's cause.
+func (v *VoteAggregator) RunMessages(ctx context.Context, msgs []Message) (string, error) {
+	decision := v.Aggregate(msgs)
+	setCause(ctx, decision.CauseBy)
+	return decision.Content, nil
+}
+
+// Aggregate counts the approvals among reviews and returns the decision.
+func (v *VoteAggregator) Aggregate(reviews []Message) Message {
+	cause := v.ReviewCause
+	if cause == "" {
+		cause = CauseWriteReview
+	}
+
+	total, approvals := 0, 0
+	for _, r := range reviews {
+		if r.CauseBy != cause {
+			continue
+		}
+		total++
+		if v.approves(r) {
+			approvals++
+		}
+	}
+
+	need := v.Threshold
+	if need <= 0 {
+		need = total/2 + 1
+	}
+	decision := CauseRejected
+	if total > 0 && approvals >= need {
+		decision = CauseApproved
+	}
+	content := fmt.Sprintf("%s: %d of %d reviewers approved (%d needed)", decision, approvals, total, need)
+	return NewMessage(v.Clock, content, "VoteAggregator", decision)
+}
+
+func (v *VoteAggregator) approves(r Message) bool {
+	if v.Verdict != nil {
+		return v.Verdict(r)
+	}
+	approve, _ := ParseVerdict(r.Content)
+	return approve
+}
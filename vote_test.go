@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func reviews(contents ...string) []Message {
+	var msgs []Message
+	for _, c := range contents {
+		msgs = append(msgs, NewMessage(nil, c, "SimpleReviewer", CauseWriteReview))
+	}
+	return msgs
+}
+
+func TestParseVerdict(t *testing.T) {
+	for content, want := range map[string][2]bool{
+		"Looks fine.\nVERDICT: APPROVE":         {true, true},
+		"verdict: reject\nLGTM otherwise":       {false, true},
+		"LGTM":                                  {true, true},
+		"Requesting changes to the error paths": {false, true},
+		"approve once the rejected tests pass":  {false, false},
+		"a few remarks":                         {false, false},
+	} {
+		if approve, ok := ParseVerdict(content); approve != want[0] || ok != want[1] {
+			t.Errorf("ParseVerdict(%q) = %v, %v, want %v, %v", content, approve, ok, want[0], want[1])
+		}
+	}
+}
+
+func TestVoteAggregator(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		threshold int
+		reviews   []Message
+		want      CauseBy
+	}{
+		{"majority", 0, reviews("VERDICT: APPROVE", "LGTM", "VERDICT: REJECT"), CauseApproved},
+		{"tie", 0, reviews("VERDICT: APPROVE", "VERDICT: REJECT"), CauseRejected},
+		{"unclear counts as no", 0, reviews("VERDICT: APPROVE", "looks odd"), CauseRejected},
+		{"threshold", 1, reviews("VERDICT: APPROVE", "VERDICT: REJECT"), CauseApproved},
+		{"no reviews", 0, nil, CauseRejected},
+		{"other causes ignored", 0, append(reviews("LGTM"), NewMessage(nil, "VERDICT: REJECT", "SimpleTester", CauseWriteTest)), CauseApproved},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &VoteAggregator{Threshold: tc.threshold}
+			if got := v.Aggregate(tc.reviews).CauseBy; got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVoteAggregatorDecisionIsWatchable(t *testing.T) {
+	team := newPipelineTeam(t, newPipelineProvider())
+	team.AddRole(&Role{Name: "Lead", Profile: "Lead", Actions: []Action{&VoteAggregator{}}, WatchList: []CauseBy{CauseWriteReview}})
+	team.AddRole(&Role{Name: "Releaser", Profile: "Releaser", Actions: []Action{echoAction{"Release"}}, WatchList: []CauseBy{CauseApproved}})
+
+	msgs, err := team.RunProject(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := causes(msgs)
+	i := slices.Index(got, CauseApproved)
+	if i < 0 {
+		t.Fatalf("no approval among %v", got)
+	}
+	if j := slices.Index(got, "Release"); j < i {
+		t.Errorf("releaser did not act on the approval: %v", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "math/rand"
+
+// selectedActions returns the action Act should run: the first one unless
+// ActionWeights gives other actions a chance.
+func (r *Role) selectedActions() []Action {
+	i := pickWeighted(r.ActionWeights, len(r.Actions), r.Rand)
+	if i < 0 {
+		return nil
+	}
+	return r.Actions[i : i+1]
+}
+
+// pickWeighted picks an index below n with probability proportional to
+// weights; missing or non-positive weights never win. Without any positive
+// weight it picks 0, and -1 when n is 0.
+func pickWeighted(weights []float64, n int, rng *rand.Rand) int {
+	if n == 0 {
+		return -1
+	}
+	total := 0.0
+	for i := 0; i < n && i < len(weights); i++ {
+		if weights[i] > 0 {
+			total += weights[i]
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var x float64
+	if rng != nil {
+		x = rng.Float64() * total
+	} else {
+		x = rand.Float64() * total
+	}
+	last := 0
+	for i := 0; i < n && i < len(weights); i++ {
+		if weights[i] <= 0 {
+			continue
+		}
+		if x < weights[i] {
+			return i
+		}
+		x -= weights[i]
+		last = i
+	}
+	return last
+}
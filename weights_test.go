@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestPickWeightedDefaultsToFirst(t *testing.T) {
+	for _, weights := range [][]float64{nil, {}, {0, 0}, {-1, 0, 0}} {
+		if got := pickWeighted(weights, 3, nil); got != 0 {
+			t.Errorf("weights %v picked %d, want 0", weights, got)
+		}
+	}
+	if got := pickWeighted(nil, 0, nil); got != -1 {
+		t.Errorf("no actions picked %d, want -1", got)
+	}
+}
+
+func TestPickWeightedSeeded(t *testing.T) {
+	weights := []float64{1, 0, 3}
+	pick := func(seed int64) []int {
+		rng := rand.New(rand.NewSource(seed))
+		var out []int
+		for i := 0; i < 200; i++ {
+			out = append(out, pickWeighted(weights, 3, rng))
+		}
+		return out
+	}
+
+	a, b := pick(42), pick(42)
+	if !slices.Equal(a, b) {
+		t.Fatal("the same seed picked different actions")
+	}
+	counts := map[int]int{}
+	for _, i := range a {
+		counts[i]++
+	}
+	if counts[1] != 0 {
+		t.Errorf("zero-weight action picked %d times", counts[1])
+	}
+	if counts[2] < 2*counts[0] {
+		t.Errorf("picks %v do not follow the 1:3 weights", counts)
+	}
+}
+
+func TestSelectedActionsSeeded(t *testing.T) {
+	first, second := echoAction{"First"}, echoAction{"Second"}
+	r := &Role{Actions: []Action{first, second}}
+	for i := 0; i < 10; i++ {
+		if got := r.selectedActions()[0]; got != first {
+			t.Fatalf("unweighted role picked %v", got.Name())
+		}
+	}
+	r.ActionWeights, r.Rand = []float64{0, 1}, rand.New(rand.NewSource(1))
+	if got := r.selectedActions()[0]; got != second {
+		t.Errorf("picked %v, want the only weighted action", got.Name())
+	}
+}